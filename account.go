@@ -0,0 +1,262 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+)
+
+// The settings of the authenticated user's account
+type AccountSettings interface {
+	GetScreenName() string
+	GetProtected() bool
+	GetGeoEnabled() bool
+	GetLanguage() string
+	// The tz database name, e.g. Europe/Berlin
+	GetTimeZone() string
+	GetSleepTime() SleepTime
+	// Who may send direct messages, all or following
+	GetAllowDmsFrom() string
+	GetDiscoverableByEmail() bool
+	// Where the trends shown to the user come from
+	GetTrendLocations() []Location
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+// Hours in which Twitter doesn't send notifications. Hours are 0-23 and in
+// the user's time zone.
+type SleepTime struct {
+	Enabled   bool `json:"enabled"`
+	StartTime int  `json:"start_time"`
+	EndTime   int  `json:"end_time"`
+}
+
+// Changes to the account settings, see UpdateAccountSettings. Zero values
+// are left unchanged.
+type AccountSettingsUpdate struct {
+	// A language code such as en or de
+	Lang string
+
+	// A Rails time zone name such as Europe/Berlin
+	TimeZone string
+
+	// The woeid of the place to show trends for
+	TrendLocationWoeid int64
+
+	SleepTime *SleepTime
+}
+
+// Changes to the profile, see UpdateProfile. Empty fields are left
+// unchanged.
+type ProfileUpdate struct {
+	Name        string
+	Url         string
+	Location    string
+	Description string
+}
+
+type tTwitterAccountSettings struct {
+	Screen_name string `json:"screen_name"`
+	Protected   bool   `json:"protected"`
+	Geo_enabled bool   `json:"geo_enabled"`
+	Language    string `json:"language"`
+	Time_zone   struct {
+		Name        string `json:"name"`
+		Utc_offset  int    `json:"utc_offset"`
+		Tzinfo_name string `json:"tzinfo_name"`
+	} `json:"time_zone"`
+	Sleep_time            SleepTime          `json:"sleep_time"`
+	Allow_dms_from        string             `json:"allow_dms_from"`
+	Discoverable_by_email bool               `json:"discoverable_by_email"`
+	Trend_location        []tTwitterLocation `json:"trend_location"`
+	tJsonFields
+}
+
+func (self *tTwitterAccountSettings) UnmarshalJSON(data []byte) error {
+	type plain tTwitterAccountSettings
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterAccountSettings) MarshalJSON() ([]byte, error) {
+	type plain tTwitterAccountSettings
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterAccountSettings) GetScreenName() string { return self.Screen_name }
+
+func (self *tTwitterAccountSettings) GetProtected() bool { return self.Protected }
+
+func (self *tTwitterAccountSettings) GetGeoEnabled() bool { return self.Geo_enabled }
+
+func (self *tTwitterAccountSettings) GetLanguage() string { return self.Language }
+
+func (self *tTwitterAccountSettings) GetTimeZone() string {
+	return self.Time_zone.Tzinfo_name
+}
+
+func (self *tTwitterAccountSettings) GetSleepTime() SleepTime { return self.Sleep_time }
+
+func (self *tTwitterAccountSettings) GetAllowDmsFrom() string { return self.Allow_dms_from }
+
+func (self *tTwitterAccountSettings) GetDiscoverableByEmail() bool {
+	return self.Discoverable_by_email
+}
+
+func (self *tTwitterAccountSettings) GetTrendLocations() []Location {
+	locations := make([]Location, len(self.Trend_location))
+	for i := range self.Trend_location {
+		locations[i] = &self.Trend_location[i]
+	}
+	return locations
+}
+
+// Builds the account/settings parameters for the update
+func (self *AccountSettingsUpdate) values() url.Values {
+	params := url.Values{}
+
+	if self.Lang != "" {
+		params.Set("lang", self.Lang)
+	}
+	if self.TimeZone != "" {
+		params.Set("time_zone", self.TimeZone)
+	}
+	if self.TrendLocationWoeid != 0 {
+		params.Set("trend_location_woeid", strconv.FormatInt(self.TrendLocationWoeid, 10))
+	}
+	if self.SleepTime != nil {
+		params.Set("sleep_time_enabled", strconv.FormatBool(self.SleepTime.Enabled))
+		params.Set("start_sleep_time", strconv.Itoa(self.SleepTime.StartTime))
+		params.Set("end_sleep_time", strconv.Itoa(self.SleepTime.EndTime))
+	}
+
+	return params
+}
+
+// Builds the account/update_profile parameters for the update
+func (self *ProfileUpdate) values() url.Values {
+	params := url.Values{}
+
+	if self.Name != "" {
+		params.Set("name", self.Name)
+	}
+	if self.Url != "" {
+		params.Set("url", self.Url)
+	}
+	if self.Location != "" {
+		params.Set("location", self.Location)
+	}
+	if self.Description != "" {
+		params.Set("description", self.Description)
+	}
+
+	return params
+}
+
+// Returns the authenticated user, or a *TwitterError with status code 401
+// if the credentials are wrong
+func (self *Api) VerifyCredentials(ctx context.Context) (User, error) {
+	user := newEmptyTwitterUser()
+	name, pass := self.requestCredentials(ctx)
+
+	ctx = withCredentials(ctx, name, pass)
+	if err := self.callJson(ctx, "GET", _QUERY_VERIFYCREDENTIALS, nil, user); err != nil {
+		return nil, err
+	}
+
+	// Unless SetCredentials ran in the meantime
+	self.authLock.Lock()
+	if self.user == name && self.pass == pass {
+		self.myId = user.GetId()
+	}
+	self.authLock.Unlock()
+
+	return self.internUser(user), nil
+}
+
+// Like SetCredentials, but checks the credentials with Twitter first and
+// returns the authenticated user. The previous credentials stay in use
+// while the check runs and are kept if it fails.
+func (self *Api) SetVerifiedCredentials(ctx context.Context, username,
+	password string) (User, error) {
+	me, err := self.VerifyCredentials(withCredentials(ctx, username, password))
+	if err != nil {
+		return nil, err
+	}
+
+	self.authLock.Lock()
+	self.user, self.pass, self.myId = username, password, me.GetId()
+	self.authLock.Unlock()
+
+	return me, nil
+}
+
+// Returns the settings of the authenticated user's account
+func (self *Api) GetAccountSettings(ctx context.Context) (AccountSettings, error) {
+	return self.accountSettingsCall(ctx, "GET", nil)
+}
+
+// Changes the settings of the authenticated user's account and returns
+// them as updated
+func (self *Api) UpdateAccountSettings(ctx context.Context,
+	update AccountSettingsUpdate) (AccountSettings, error) {
+	return self.accountSettingsCall(ctx, "POST", update.values())
+}
+
+func (self *Api) accountSettingsCall(ctx context.Context, method string,
+	params url.Values) (AccountSettings, error) {
+	settings := new(tTwitterAccountSettings)
+	if err := self.callJson(ctx, method, _QUERY_ACCOUNTSETTINGS, params, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Changes the profile of the authenticated user and returns the user
+func (self *Api) UpdateProfile(ctx context.Context, update ProfileUpdate) (User, error) {
+	return self.profileCall(ctx, _QUERY_UPDATEPROFILE, update.values())
+}
+
+// Replaces the profile image of the authenticated user with a GIF, JPEG
+// or PNG image of at most 700 kB and returns the user. The new image may
+// take a few seconds to show up.
+func (self *Api) UpdateProfileImage(ctx context.Context, image []byte) (User, error) {
+	params := url.Values{"image": {base64.StdEncoding.EncodeToString(image)}}
+	return self.profileCall(ctx, _QUERY_UPDATEPROFILEIMAGE, params)
+}
+
+// Replaces the profile banner of the authenticated user. Banners are
+// cropped to 1500x500 pixels and may be at most 5 MB.
+func (self *Api) UpdateProfileBanner(ctx context.Context, image []byte) error {
+	params := url.Values{"banner": {base64.StdEncoding.EncodeToString(image)}}
+	return self.callJson(ctx, "POST", _QUERY_UPDATEPROFILEBANNER, params, nil)
+}
+
+// Removes the profile banner of the authenticated user
+func (self *Api) RemoveProfileBanner(ctx context.Context) error {
+	return self.callJson(ctx, "POST", _QUERY_REMOVEPROFILEBANNER, url.Values{}, nil)
+}
+
+func (self *Api) profileCall(ctx context.Context, url_ string, params url.Values) (User, error) {
+	user := newEmptyTwitterUser()
+	if err := self.callJson(ctx, "POST", url_, params, user); err != nil {
+		return nil, err
+	}
+	return self.internUser(user), nil
+}
@@ -0,0 +1,107 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSetVerifiedCredentials(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		reply: func(req *http.Request) string {
+			if user, _, _ := req.BasicAuth(); user == "jb55" {
+				return `{"id":9918032,"screen_name":"jb55"}`
+			}
+			return `{"errors":[{"code":32,"message":"Could not authenticate you."}]}`
+		},
+	})
+	transport := api.httpClient.Transport.(*tFakeTransport)
+
+	transport.status = 401
+	if _, err := api.SetVerifiedCredentials(context.Background(), "bad", "pw"); err == nil {
+		t.Fatalf("SetVerifiedCredentials: bad credentials accepted")
+	} else if e := new(TwitterError); !errors.As(err, &e) || e.GetCode() != 32 {
+		t.Errorf("SetVerifiedCredentials: got %v", err)
+	}
+	if api.user != "jb55" {
+		t.Errorf("previous credentials not restored, user is %q", api.user)
+	}
+
+	transport.status = 200
+	me, err := api.SetVerifiedCredentials(context.Background(), "jb55", "secret")
+	if err != nil {
+		t.Fatalf("SetVerifiedCredentials: %s", err)
+	}
+	if me.GetScreenName() != "jb55" || api.myId != 9918032 {
+		t.Errorf("got %q with id %d", me.GetScreenName(), api.myId)
+	}
+}
+
+func TestSetVerifiedCredentialsSwapsAtOnce(t *testing.T) {
+	var api *Api
+	api = newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			// Other requests keep using the old credentials during the check
+			if user, pass := api.credentials(); user != "jb55" || pass != "secret" {
+				t.Errorf("credentials changed to %s:%s before the check finished", user, pass)
+			}
+			user, _, _ := req.BasicAuth()
+			return `{"id":2,"screen_name":"` + user + `"}`
+		},
+	})
+
+	if _, err := api.SetVerifiedCredentials(context.Background(), "rob", "pw"); err != nil {
+		t.Fatalf("SetVerifiedCredentials: %s", err)
+	}
+	if user, pass := api.credentials(); user != "rob" || pass != "pw" || api.myId != 2 {
+		t.Errorf("got %s:%s with id %d expected rob:pw with id 2", user, pass, api.myId)
+	}
+}
+
+func TestUpdateAccountSettings(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body: `{"screen_name":"jb55","language":"de","time_zone":{"name":"Berlin",` +
+			`"utc_offset":3600,"tzinfo_name":"Europe/Berlin"},"sleep_time":{"enabled":true,` +
+			`"start_time":23,"end_time":7},"trend_location":[{"name":"Berlin","woeid":638242}]}`,
+		check: func(req *http.Request) {
+			req.ParseForm()
+			if req.PostForm.Get("lang") != "de" || req.PostForm.Get("start_sleep_time") != "23" ||
+				req.PostForm.Has("time_zone") {
+				t.Errorf("got form %v", req.PostForm)
+			}
+		},
+	})
+
+	settings, err := api.UpdateAccountSettings(context.Background(), AccountSettingsUpdate{
+		Lang:      "de",
+		SleepTime: &SleepTime{Enabled: true, StartTime: 23, EndTime: 7},
+	})
+	if err != nil {
+		t.Fatalf("UpdateAccountSettings: %s", err)
+	}
+	if settings.GetTimeZone() != "Europe/Berlin" || settings.GetSleepTime().EndTime != 7 {
+		t.Errorf("got time zone %q sleep time %v", settings.GetTimeZone(), settings.GetSleepTime())
+	}
+	if locations := settings.GetTrendLocations(); len(locations) != 1 ||
+		locations[0].GetWoeid() != 638242 {
+		t.Errorf("GetTrendLocations: got %v", locations)
+	}
+}
@@ -16,13 +16,17 @@
 package twitter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"encoding/json"
 	"strconv"
 	"time"
 	"regexp"
+	"net/http"
 	"net/url"
+	"sync"
 )
 
 const (
@@ -35,7 +39,7 @@ const (
 	kDefaultTimelineAlloc = 20
 
 	_QUERY_GETSTATUS       = "http://www.twitter.com/statuses/show/%d.json"
-	_QUERY_UPDATESTATUS    = "http://www.twitter.com/statuses/update/update.json"
+	_QUERY_UPDATESTATUS    = "https://api.twitter.com/1.1/statuses/update.json"
 	_QUERY_PUBLICTIMELINE  = "http://www.twitter.com/statuses/public_timeline.json"
 	_QUERY_USERTIMELINE    = "http://www.twitter.com/statuses/user_timeline.json"
 	_QUERY_REPLIES         = "http://www.twitter.com/statuses/mentions.json"
@@ -45,6 +49,72 @@ const (
 	_QUERY_USER_DEFAULT    = "http://www.twitter.com/%s.json"
 	_QUERY_SEARCH          = "http://search.twitter.com/search.json"
 	_QUERY_RATELIMIT       = "http://twitter.com/account/rate_limit_status.json"
+
+	_QUERY_DESTROYSTATUS  = "https://api.twitter.com/1.1/statuses/destroy/%d.json"
+	_QUERY_RETWEET        = "https://api.twitter.com/1.1/statuses/retweet/%d.json"
+	_QUERY_UNRETWEET      = "https://api.twitter.com/1.1/statuses/unretweet/%d.json"
+	_QUERY_FAVORITE       = "https://api.twitter.com/1.1/favorites/create.json"
+	_QUERY_UNFAVORITE     = "https://api.twitter.com/1.1/favorites/destroy.json"
+	_QUERY_FAVORITES      = "https://api.twitter.com/1.1/favorites/list.json"
+	_QUERY_SEARCHSTATUSES = "https://api.twitter.com/1.1/search/tweets.json"
+
+	_QUERY_FOLLOW              = "https://api.twitter.com/1.1/friendships/create.json"
+	_QUERY_UNFOLLOW            = "https://api.twitter.com/1.1/friendships/destroy.json"
+	_QUERY_SHOWFRIENDSHIP      = "https://api.twitter.com/1.1/friendships/show.json"
+	_QUERY_LOOKUPFRIENDSHIPS   = "https://api.twitter.com/1.1/friendships/lookup.json"
+	_QUERY_INCOMINGFRIENDSHIPS = "https://api.twitter.com/1.1/friendships/incoming.json"
+	_QUERY_OUTGOINGFRIENDSHIPS = "https://api.twitter.com/1.1/friendships/outgoing.json"
+	_QUERY_UPDATEFRIENDSHIP    = "https://api.twitter.com/1.1/friendships/update.json"
+	_QUERY_LOOKUPUSERS         = "https://api.twitter.com/1.1/users/lookup.json"
+	_QUERY_FOLLOWERIDS         = "https://api.twitter.com/1.1/followers/ids.json"
+	_QUERY_FRIENDIDS           = "https://api.twitter.com/1.1/friends/ids.json"
+
+	_QUERY_VERIFYCREDENTIALS    = "https://api.twitter.com/1.1/account/verify_credentials.json"
+	_QUERY_DIRECTMESSAGES       = "https://api.twitter.com/1.1/direct_messages/events/list.json"
+	_QUERY_SHOWDIRECTMESSAGE    = "https://api.twitter.com/1.1/direct_messages/events/show.json"
+	_QUERY_NEWDIRECTMESSAGE     = "https://api.twitter.com/1.1/direct_messages/events/new.json"
+	_QUERY_DESTROYDIRECTMESSAGE = "https://api.twitter.com/1.1/direct_messages/events/destroy.json"
+
+	_QUERY_CREATELIST        = "https://api.twitter.com/1.1/lists/create.json"
+	_QUERY_UPDATELIST        = "https://api.twitter.com/1.1/lists/update.json"
+	_QUERY_DESTROYLIST       = "https://api.twitter.com/1.1/lists/destroy.json"
+	_QUERY_ADDLISTMEMBERS    = "https://api.twitter.com/1.1/lists/members/create_all.json"
+	_QUERY_REMOVELISTMEMBERS = "https://api.twitter.com/1.1/lists/members/destroy_all.json"
+	_QUERY_LISTMEMBERS       = "https://api.twitter.com/1.1/lists/members.json"
+	_QUERY_LISTMEMBERSHIPS   = "https://api.twitter.com/1.1/lists/memberships.json"
+	_QUERY_LISTSUBSCRIPTIONS = "https://api.twitter.com/1.1/lists/subscriptions.json"
+	_QUERY_LISTOWNERSHIPS    = "https://api.twitter.com/1.1/lists/ownerships.json"
+	_QUERY_LISTSTATUSES      = "https://api.twitter.com/1.1/lists/statuses.json"
+
+	_QUERY_BLOCK         = "https://api.twitter.com/1.1/blocks/create.json"
+	_QUERY_UNBLOCK       = "https://api.twitter.com/1.1/blocks/destroy.json"
+	_QUERY_BLOCKEDIDS    = "https://api.twitter.com/1.1/blocks/ids.json"
+	_QUERY_MUTE          = "https://api.twitter.com/1.1/mutes/users/create.json"
+	_QUERY_UNMUTE        = "https://api.twitter.com/1.1/mutes/users/destroy.json"
+	_QUERY_MUTEDIDS      = "https://api.twitter.com/1.1/mutes/users/ids.json"
+	_QUERY_MUTEKEYWORD   = "https://api.twitter.com/1.1/mutes/keywords/create.json"
+	_QUERY_UNMUTEKEYWORD = "https://api.twitter.com/1.1/mutes/keywords/destroy.json"
+	_QUERY_MUTEDKEYWORDS = "https://api.twitter.com/1.1/mutes/keywords/list.json"
+	_QUERY_REPORTSPAM    = "https://api.twitter.com/1.1/users/report_spam.json"
+
+	_QUERY_TRENDSPLACE     = "https://api.twitter.com/1.1/trends/place.json"
+	_QUERY_TRENDSAVAILABLE = "https://api.twitter.com/1.1/trends/available.json"
+	_QUERY_TRENDSCLOSEST   = "https://api.twitter.com/1.1/trends/closest.json"
+
+	_QUERY_REVERSEGEOCODE = "https://api.twitter.com/1.1/geo/reverse_geocode.json"
+	_QUERY_GEOSEARCH      = "https://api.twitter.com/1.1/geo/search.json"
+	_QUERY_PLACE          = "https://api.twitter.com/1.1/geo/id/"
+
+	_QUERY_SAVEDSEARCHES      = "https://api.twitter.com/1.1/saved_searches/list.json"
+	_QUERY_SAVEDSEARCH        = "https://api.twitter.com/1.1/saved_searches/show/%d.json"
+	_QUERY_CREATESAVEDSEARCH  = "https://api.twitter.com/1.1/saved_searches/create.json"
+	_QUERY_DESTROYSAVEDSEARCH = "https://api.twitter.com/1.1/saved_searches/destroy/%d.json"
+
+	_QUERY_ACCOUNTSETTINGS     = "https://api.twitter.com/1.1/account/settings.json"
+	_QUERY_UPDATEPROFILE       = "https://api.twitter.com/1.1/account/update_profile.json"
+	_QUERY_UPDATEPROFILEIMAGE  = "https://api.twitter.com/1.1/account/update_profile_image.json"
+	_QUERY_UPDATEPROFILEBANNER = "https://api.twitter.com/1.1/account/update_profile_banner.json"
+	_QUERY_REMOVEPROFILEBANNER = "https://api.twitter.com/1.1/account/remove_profile_banner.json"
 )
 
 const (
@@ -59,12 +129,27 @@ const (
 )
 
 type TwitterError struct {
-	error string
-}
+	error      string
+	statusCode int
+	code       int
+}
+
+// Errors a *TwitterError can be matched against with errors.Is
+var (
+	ErrAlreadyFavorited = errors.New(kErr + "status is already favorited")
+	ErrAlreadyRetweeted = errors.New(kErr + "status is already retweeted")
+	ErrNotFound         = errors.New(kErr + "not found")
+	ErrNotYours         = errors.New(kErr + "status belongs to another user")
+	ErrDuplicateStatus  = errors.New(kErr + "status is a duplicate")
+)
 
 type Api struct {
-	user           string
-	pass           string
+	// Guards user, pass and myId, which change together
+	authLock sync.Mutex
+	user     string
+	pass     string
+	myId     int64
+
 	errors         chan error
 	lastError      error
 	client         string
@@ -72,11 +157,43 @@ type Api struct {
 	clientVersion  string
 	userAgent      string
 	receiveChannel interface{}
+	httpClient     *http.Client
+	cacheLock      sync.Mutex
+	cache          Cache
+	cacheTTLs      map[string]time.Duration
+	flights        tFlightGroup
+	entities       *EntityStore
 }
 
 // type that satisfies the os.Error interface
 func (self TwitterError) Error() string { return self.error }
 
+// Returns the HTTP status code of the failed request, or 0 if the error
+// didn't come from an HTTP response
+func (self TwitterError) GetStatusCode() int { return self.statusCode }
+
+// Returns the Twitter error code sent with the response, or 0 if there
+// was none. See https://developer.twitter.com/en/docs/basics/response-codes
+func (self TwitterError) GetCode() int { return self.code }
+
+// Matches the error against ErrAlreadyFavorited, ErrAlreadyRetweeted,
+// ErrNotFound, ErrNotYours and ErrDuplicateStatus
+func (self TwitterError) Is(target error) bool {
+	switch target {
+	case ErrAlreadyFavorited:
+		return self.code == 139
+	case ErrAlreadyRetweeted:
+		return self.code == 327
+	case ErrNotFound:
+		return self.code == 34 || self.code == 144 || self.statusCode == 404
+	case ErrNotYours:
+		return self.code == 179 || self.code == 183
+	case ErrDuplicateStatus:
+		return self.code == 187
+	}
+	return false
+}
+
 // Creates and initializes new Api objec
 func NewApi() *Api {
 	api := new(Api)
@@ -84,9 +201,53 @@ func NewApi() *Api {
 	return api
 }
 
+// Only tells whether credentials were given, SetVerifiedCredentials checks
+// them with Twitter
 func (self *Api) isAuthed() bool {
-	// TODO: validate user and pass
-	return self.user != "" && self.pass != ""
+	user, pass := self.credentials()
+	return user != "" && pass != ""
+}
+
+func (self *Api) credentials() (user, pass string) {
+	self.authLock.Lock()
+	defer self.authLock.Unlock()
+
+	return self.user, self.pass
+}
+
+type tCredentials struct{ user, pass string }
+
+type tCredentialsKey struct{}
+
+// Makes the requests of ctx use the given credentials instead of the
+// current ones, so a call keeps its identity while SetCredentials runs
+func withCredentials(ctx context.Context, user, pass string) context.Context {
+	return context.WithValue(ctx, tCredentialsKey{}, tCredentials{user, pass})
+}
+
+// Returns the credentials a request with ctx is sent with
+func (self *Api) requestCredentials(ctx context.Context) (user, pass string) {
+	if c, ok := ctx.Value(tCredentialsKey{}).(tCredentials); ok {
+		return c.user, c.pass
+	}
+	return self.credentials()
+}
+
+// Returns the id of the authenticated user, asking Twitter the first time
+func (self *Api) authenticatedUserId(ctx context.Context) (int64, error) {
+	self.authLock.Lock()
+	myId := self.myId
+	self.authLock.Unlock()
+
+	if myId != 0 {
+		return myId, nil
+	}
+
+	me, err := self.VerifyCredentials(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return me.GetId(), nil
 }
 
 // Returns the last error sent to the error channel.
@@ -102,13 +263,12 @@ func (self *Api) GetLastError() error {
 // of twitter.User instances
 //
 // user:
-//  A user id or name to fetch the followers from. If this argument
-//  is nil, then the followers are fetched from the authenticated user.
-//  This paramater must be an int, int64, or string.
+//  The user to fetch the followers from. Pass Me() to fetch the
+//  followers of the authenticated user.
 //
 // page:
 //  Not yet implemented
-func (self *Api) GetFollowers(user interface{}, page int) <-chan []User {
+func (self *Api) GetFollowers(user UserRef, page int) <-chan []User {
 	return self.getUsersByType(user, page, "statuses/followers")
 }
 
@@ -116,27 +276,51 @@ func (self *Api) GetFollowers(user interface{}, page int) <-chan []User {
 // of twitter.User instances
 //
 // user:
-//  A user id or name to fetch the friends from. If this argument
-//  is nil, then the friends are fetched from the authenticated user.
-//  This paramater must be an int, int64, or string.
+//  The user to fetch the friends from. Pass Me() to fetch the
+//  friends of the authenticated user.
 //
 // page:
 //  Not yet implemented
-func (self *Api) GetFriends(user interface{}, page int) <-chan []User {
+func (self *Api) GetFriends(user UserRef, page int) <-chan []User {
 	return self.getUsersByType(user, page, "statuses/friends")
 }
 
-func (self *Api) getUsersByType(user interface{}, page int, typ string) <-chan []User {
-	var url_ string
-	var ok bool
-	responseChannel := self.buildRespChannel(_SLICEUSER).(chan []User)
+// Returns the ids of every follower of a user, fetching all pages. Much
+// cheaper than GetFollowers for large accounts, see IDSet for comparing
+// the results.
+//
+// user:
+//  The user to fetch the followers from. Pass Me() to fetch the
+//  followers of the authenticated user.
+func (self *Api) FollowerIDs(ctx context.Context, user UserRef) ([]int64, error) {
+	params := url.Values{}
+	if err := user.addParams(params, ""); err != nil {
+		return nil, err
+	}
+	return self.getIds(ctx, _QUERY_FOLLOWERIDS, params)
+}
 
-	if url_, ok = self.buildUserUrl(typ, user, page); !ok {
-		responseChannel <- nil
-		return responseChannel
+// Returns the ids of every user a user follows, fetching all pages
+//
+// user:
+//  The user to fetch the friends from. Pass Me() to fetch the
+//  friends of the authenticated user.
+func (self *Api) FriendIDs(ctx context.Context, user UserRef) ([]int64, error) {
+	params := url.Values{}
+	if err := user.addParams(params, ""); err != nil {
+		return nil, err
 	}
+	return self.getIds(ctx, _QUERY_FRIENDIDS, params)
+}
 
-	go self.goGetUsers(url_, responseChannel)
+func (self *Api) getUsersByType(user UserRef, page int, typ string) <-chan []User {
+	responseChannel := self.buildRespChannel(_SLICEUSER).(chan []User)
+	if err := user.check(); err != nil {
+		self.reportTwitterError(err)
+		go func() { responseChannel <- []User{} }()
+		return responseChannel
+	}
+	go self.goGetUsers(self.buildUserUrl(typ, user, page), responseChannel)
 	return responseChannel
 }
 
@@ -202,40 +386,18 @@ func (self *Api) Search(query string, page int, perPage int, sinceId int, locale
 }
 
 // Returns a channel which receives a twitter.User instance for the given
-// username.
-//
-// id:
-//  A twiter user id
-func (self *Api) GetUserById(id int64) <-chan User {
-	var url_ string
-	var ok bool
-	responseChannel := self.buildRespChannel(_USER).(chan User)
-
-	if url_, ok = self.buildUserUrl("users/show", id, 0); !ok {
-		responseChannel <- nil
-		return responseChannel
-	}
-
-	go self.goGetUser(url_, responseChannel)
-	return responseChannel
-}
-
-// Returns a channel which receives a twitter.User instance for the given
-// username.
+// user.
 //
-// name:
-//  The screenname of the user
-func (self *Api) GetUser(name string) <-chan User {
-	var url_ string
-	var ok bool
+// user:
+//  The user to fetch, Me() fetches the authenticated user
+func (self *Api) GetUser(user UserRef) <-chan User {
 	responseChannel := self.buildRespChannel(_USER).(chan User)
-
-	if url_, ok = self.buildUserUrl("users/show", name, 0); !ok {
-		responseChannel <- nil
+	if err := user.check(); err != nil {
+		self.reportTwitterError(err)
+		go func() { responseChannel <- newEmptyTwitterUser() }()
 		return responseChannel
 	}
-
-	go self.goGetUser(url_, responseChannel)
+	go self.goGetUser(self.buildUserUrl("users/show", user, 0), responseChannel)
 	return responseChannel
 }
 
@@ -422,7 +584,7 @@ func (self *Api) getStatuses(url_ string) []Status {
 	timeline = make([]Status, dummyLen)
 
 	for i := 0; i < dummyLen; i++ {
-		status := &timelineDummy.Object[i]
+		status := self.internStatus(&timelineDummy.Object[i])
 		timeline[i] = status
 		if err := status.GetError(); err != "" {
 			self.reportError(err)
@@ -466,7 +628,7 @@ func (self *Api) getUsers(url_ string) []User {
 	users = make([]User, dummyLen)
 
 	for i := 0; i < dummyLen; i++ {
-		user := &usersDummy.Object[i]
+		user := self.internUser(&usersDummy.Object[i])
 		users[i] = user
 		if err := user.GetError(); err != "" {
 			self.reportError(err)
@@ -476,6 +638,33 @@ func (self *Api) getUsers(url_ string) []User {
 	return users
 }
 
+// Fetches every page of a cursored list of ids
+func (self *Api) getIds(ctx context.Context, url_ string, params url.Values) ([]int64, error) {
+	var ids []int64
+	cursor := int64(-1)
+	seen := make(map[int64]bool)
+
+	// A server handing out a cursor twice would keep us going forever
+	for cursor != 0 && !seen[cursor] {
+		seen[cursor] = true
+
+		var page struct {
+			Ids         []int64
+			Next_cursor int64
+		}
+
+		params.Set("cursor", strconv.FormatInt(cursor, 10))
+		if err := self.callJson(ctx, "GET", url_, params, &page); err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, page.Ids...)
+		cursor = page.Next_cursor
+	}
+
+	return ids, nil
+}
+
 // Sets the Twitter client header, aka the X-Twitter-Client http header on
 // all POST operations
 func (self *Api) SetClientString(client string) {
@@ -490,23 +679,34 @@ func (self *Api) init() {
 	self.clientURL = kDefaultClientURL
 	self.clientVersion = kDefaultClientVersion
 	self.userAgent = kDefaultUserAgent
+	self.httpClient = http.DefaultClient
+	self.initCache()
 }
 
+// Overrides the http.Client used for REST calls, http.DefaultClient by
+// default. Useful to set timeouts or a custom Transport, such as one signing
+// requests with OAuth for the 1.1 endpoints.
+func (self *Api) SetHTTPClient(client *http.Client) { self.httpClient = client }
+
 // Overrides the default user agent (go-twitter)
 func (self *Api) SetUserAgent(agent string) { self.userAgent = agent }
 
 // Sets the username and password string for all subsequent authorized
-// HTTP requests
+// HTTP requests. Use SetVerifiedCredentials to find out right away whether
+// they work.
 func (self *Api) SetCredentials(username, password string) {
+	self.authLock.Lock()
+	defer self.authLock.Unlock()
+
 	self.user = username
 	self.pass = password
+	self.myId = 0
 }
 
 // Disable Twitter authentication, subsequent REST calls will not use
 // Authentication
 func (self *Api) ClearCredentials() {
-	self.user = ""
-	self.pass = ""
+	self.SetCredentials("", "")
 }
 
 // Returns a channel which receives API errors. Can be used for logging
@@ -532,7 +732,11 @@ func (self *Api) GetErrorChannel() <-chan error {
 
 // Post a Twitter status message to the authenticated user
 //
-// The twitter.Api instance must be authenticated
+// The twitter.Api instance must be authenticated, through a Transport
+// signing requests with OAuth as the 1.1 endpoint requires, see Do
+//
+// Returns: a channel which receives true if the status was posted. See
+//          PostStatus for more options and the created Status.
 func (self *Api) PostUpdate(status string, inReplyToId int64) <-chan bool {
 	responseChannel := self.buildRespChannel(_BOOL).(chan bool)
 
@@ -541,20 +745,12 @@ func (self *Api) PostUpdate(status string, inReplyToId int64) <-chan bool {
 }
 
 func (self *Api) goPostUpdate(status string, inReplyToId int64, response chan bool) {
-	url_ := _QUERY_UPDATESTATUS
-	var data string
+	update := StatusUpdate{Status: status, InReplyToStatusId: inReplyToId}
 
-	data = "status=" + url.QueryEscape(status)
-	if inReplyToId != 0 {
-		reply_data := fmt.Sprintf("&in_reply_to_status_id=%d", inReplyToId)
-		data += reply_data
-	}
-
-	_, err := httpPost(url_, self.user, self.pass, self.client, self.clientURL,
-		self.clientVersion, self.userAgent, data)
-	if err != nil {
-		self.reportError(kErr + err.Error())
+	if _, err := self.PostStatus(context.Background(), update); err != nil {
+		self.reportTwitterError(err)
 		response <- false
+		return
 	}
 
 	response <- true
@@ -583,7 +779,7 @@ func (self *Api) goGetUser(url_ string, response chan User) {
 	jsonString := self.getJsonFromUrl(url_)
 	json.Unmarshal([]uint8(jsonString), &user)
 
-	u := &(user.Object)
+	u := self.internUser(&user.Object)
 	if err := u.GetError(); err != "" {
 		self.reportError(err)
 	}
@@ -592,13 +788,11 @@ func (self *Api) goGetUser(url_ string, response chan User) {
 }
 
 func (self *Api) goGetStatus(id int64, response chan Status) {
-	url_ := fmt.Sprintf(_QUERY_GETSTATUS, id)
-	var status tTwitterStatusDummy
-	jsonString := self.getJsonFromUrl(url_)
-	json.Unmarshal([]uint8(jsonString), &status)
-
-	s := &(status.Object)
-	if err := s.GetError(); err != "" {
+	s, err := self.getStatus(context.Background(), id)
+	if err != nil {
+		self.reportTwitterError(err)
+		s = newEmptyTwitterStatus()
+	} else if err := s.GetError(); err != "" {
 		self.reportError(err)
 	}
 
@@ -606,7 +800,15 @@ func (self *Api) goGetStatus(id int64, response chan Status) {
 }
 
 func (self *Api) reportError(error string) {
-	err := &TwitterError{error}
+	self.reportTwitterError(&TwitterError{error: error})
+}
+
+// Like reportError, but keeps the codes of a *TwitterError
+func (self *Api) reportTwitterError(err error) {
+	if _, ok := err.(*TwitterError); !ok {
+		err = &TwitterError{error: kErr + err.Error()}
+	}
+
 	self.lastError = err
 	select {
 	case self.errors <- err: // do nothing
@@ -622,45 +824,41 @@ func (self *Api) reportError(error string) {
 	}
 }
 
+// Concurrent calls for the same URL and credentials share one request
 func (self *Api) getJsonFromUrl(url_ string) string {
-	r, error := httpGet(url_, self.user, self.pass)
-	if error != nil {
-		self.reportError(kErr + error.Error())
-		return ""
-	}
-
-	data, err := parseResponse(r)
-	data = fixBrokenJson(data)
+	data, err := self.getJson(context.Background(), url_)
 	if err != nil {
-		self.reportError(kErr + err.Error())
+		self.reportTwitterError(err)
 		return ""
 	}
 
-	return data
+	return fixBrokenJson(data)
 }
 
-func (self *Api) buildUserUrl(typ string, user interface{}, page int) (string, bool) {
-	var url_ string
-
-	if user == nil {
-		url_ = fmt.Sprintf(_QUERY_USER_DEFAULT, typ)
-		return url_, true
+// GETs url_ and returns the body. Concurrent calls for the same URL and
+// credentials share one request, which runs until the last of them stops
+// waiting; a call whose ctx is done returns ctx.Err() right away.
+func (self *Api) getJson(ctx context.Context, url_ string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url_, nil)
+	if err != nil {
+		return "", err
 	}
 
-	switch user.(type) {
-	case string:
-		url_ = fmt.Sprintf(_QUERY_USER_NAME, typ, user.(string))
-		break
-	case int64:
-		url_ = fmt.Sprintf(_QUERY_USER_ID, typ, user.(int64))
-		break
-	case int:
-		url_ = fmt.Sprintf(_QUERY_USER_ID, typ, user.(int))
-		break
-	default:
-		self.reportError("User parameter must be a string, int, or int64")
-		return "", false
+	return self.flights.do(ctx, self.cacheKey(req), func(ctx context.Context) (string, error) {
+		data, err := self.fetch(req.WithContext(ctx))
+		return string(data), err
+	})
+}
+
+// Builds the URL of a user endpoint, user must pass check
+func (self *Api) buildUserUrl(typ string, user UserRef, page int) string {
+	switch {
+	case user.me:
+	case user.screenName != "":
+		return fmt.Sprintf(_QUERY_USER_NAME, typ, user.screenName)
+	case user.id != 0:
+		return fmt.Sprintf(_QUERY_USER_ID, typ, user.id)
 	}
 
-	return url_, true
+	return fmt.Sprintf(_QUERY_USER_DEFAULT, typ)
 }
@@ -45,7 +45,7 @@ func main() {
   //api.PostUpdate("Testing my Go twitter library", 0);
 }
 
-func showFollowers(api *twitter.Api, user interface{}) {
+func showFollowers(api *twitter.Api, user twitter.UserRef) {
   followers := <-api.GetFollowers(user, 0);
 
   for _, follower := range followers {
@@ -53,7 +53,7 @@ func showFollowers(api *twitter.Api, user interface{}) {
   }
 }
 
-func showFriends(api *twitter.Api, user interface{}) {
+func showFriends(api *twitter.Api, user twitter.UserRef) {
   friends := <-api.GetFriends(user, 0);
 
   for _, friend := range friends {
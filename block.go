@@ -0,0 +1,139 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// A word or phrase the authenticated user muted
+type MutedKeyword interface {
+	GetId() string
+	GetKeyword() string
+	// Where the keyword is muted, e.g. home_timeline and notifications
+	GetMuteSurfaces() []string
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+type tTwitterMutedKeyword struct {
+	Id            string   `json:"id"`
+	Keyword       string   `json:"keyword"`
+	Mute_surfaces []string `json:"mute_surfaces"`
+	tJsonFields
+}
+
+func (self *tTwitterMutedKeyword) UnmarshalJSON(data []byte) error {
+	type plain tTwitterMutedKeyword
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterMutedKeyword) MarshalJSON() ([]byte, error) {
+	type plain tTwitterMutedKeyword
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterMutedKeyword) GetId() string { return self.Id }
+
+func (self *tTwitterMutedKeyword) GetKeyword() string { return self.Keyword }
+
+func (self *tTwitterMutedKeyword) GetMuteSurfaces() []string { return self.Mute_surfaces }
+
+// Blocks a user and returns them. Blocking also unfollows in both
+// directions.
+func (self *Api) Block(ctx context.Context, user UserRef) (User, error) {
+	return self.userAction(ctx, _QUERY_BLOCK, user, url.Values{})
+}
+
+// Unblocks a user and returns them
+func (self *Api) Unblock(ctx context.Context, user UserRef) (User, error) {
+	return self.userAction(ctx, _QUERY_UNBLOCK, user, url.Values{})
+}
+
+// Mutes a user and returns them. Their statuses no longer show up in the
+// home timeline but they can still follow and mention the authenticated
+// user.
+func (self *Api) Mute(ctx context.Context, user UserRef) (User, error) {
+	return self.userAction(ctx, _QUERY_MUTE, user, url.Values{})
+}
+
+// Unmutes a user and returns them
+func (self *Api) Unmute(ctx context.Context, user UserRef) (User, error) {
+	return self.userAction(ctx, _QUERY_UNMUTE, user, url.Values{})
+}
+
+// Reports a user as a spam account and returns them
+//
+// block:
+//  Whether to block the user as well
+func (self *Api) ReportSpam(ctx context.Context, user UserRef, block bool) (User, error) {
+	params := url.Values{"perform_block": {strconv.FormatBool(block)}}
+	return self.userAction(ctx, _QUERY_REPORTSPAM, user, params)
+}
+
+// Returns the ids of every user the authenticated user blocked
+func (self *Api) BlockedIDs(ctx context.Context) ([]int64, error) {
+	return self.getIds(ctx, _QUERY_BLOCKEDIDS, url.Values{})
+}
+
+// Returns the ids of every user the authenticated user muted
+func (self *Api) MutedIDs(ctx context.Context) ([]int64, error) {
+	return self.getIds(ctx, _QUERY_MUTEDIDS, url.Values{})
+}
+
+// Mutes a word or phrase in the home timeline and notifications.
+//
+// Keyword mutes aren't part of the documented REST API, these calls use
+// the endpoints of the web client and may stop working without notice.
+func (self *Api) MuteKeyword(ctx context.Context, keyword string) (MutedKeyword, error) {
+	muted := new(tTwitterMutedKeyword)
+	params := url.Values{
+		"keyword":       {keyword},
+		"mute_surfaces": {"notifications,home_timeline,tweet_replies"},
+		"mute_option":   {""},
+	}
+
+	if err := self.callJson(ctx, "POST", _QUERY_MUTEKEYWORD, params, muted); err != nil {
+		return nil, err
+	}
+	return muted, nil
+}
+
+// Removes keyword mutes by their ids, see MuteKeyword
+func (self *Api) UnmuteKeywords(ctx context.Context, ids ...string) error {
+	params := url.Values{"ids": {strings.Join(ids, ",")}}
+	return self.callJson(ctx, "POST", _QUERY_UNMUTEKEYWORD, params, nil)
+}
+
+// Returns the muted keywords of the authenticated user, see MuteKeyword
+func (self *Api) MutedKeywords(ctx context.Context) ([]MutedKeyword, error) {
+	var list struct {
+		Muted_keywords []tTwitterMutedKeyword
+	}
+
+	if err := self.callJson(ctx, "GET", _QUERY_MUTEDKEYWORDS, nil, &list); err != nil {
+		return nil, err
+	}
+
+	keywords := make([]MutedKeyword, len(list.Muted_keywords))
+	for i := range list.Muted_keywords {
+		keywords[i] = &list.Muted_keywords[i]
+	}
+	return keywords, nil
+}
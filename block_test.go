@@ -0,0 +1,170 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBlockAndMuteCalls(t *testing.T) {
+	var method, path string
+	var form url.Values
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"id":42,"screen_name":"spammer"}`,
+		check: func(req *http.Request) {
+			req.ParseForm()
+			method, path, form = req.Method, req.URL.Path, req.PostForm
+		},
+	})
+
+	calls := []struct {
+		name string
+		call func() (User, error)
+		path string
+		form string
+	}{
+		{"Block", func() (User, error) { return api.Block(context.Background(), ByID(42)) },
+			"/1.1/blocks/create.json", "map[user_id:[42]]"},
+		{"Unblock", func() (User, error) { return api.Unblock(context.Background(), ByScreenName("spammer")) },
+			"/1.1/blocks/destroy.json", "map[screen_name:[spammer]]"},
+		{"Mute", func() (User, error) { return api.Mute(context.Background(), ByID(42)) },
+			"/1.1/mutes/users/create.json", "map[user_id:[42]]"},
+		{"Unmute", func() (User, error) { return api.Unmute(context.Background(), ByID(42)) },
+			"/1.1/mutes/users/destroy.json", "map[user_id:[42]]"},
+		{"ReportSpam", func() (User, error) { return api.ReportSpam(context.Background(), ByID(42), true) },
+			"/1.1/users/report_spam.json", "map[perform_block:[true] user_id:[42]]"},
+	}
+
+	for _, c := range calls {
+		user, err := c.call()
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if method != "POST" || path != c.path || fmt.Sprint(form) != c.form {
+			t.Errorf("%s: sent %s %s %v expected POST %s %s", c.name, method, path, form,
+				c.path, c.form)
+		}
+		if user.GetId() != 42 || user.GetScreenName() != "spammer" {
+			t.Errorf("%s: got user %d %s", c.name, user.GetId(), user.GetScreenName())
+		}
+	}
+}
+
+func TestBlockedAndMutedIDsFollowCursors(t *testing.T) {
+	pages := map[string]string{
+		"-1": `{"ids":[1,2],"next_cursor":5}`,
+		"5":  `{"ids":[3],"next_cursor":0}`,
+	}
+	var paths []string
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			paths = append(paths, req.URL.Path)
+			return pages[req.URL.Query().Get("cursor")]
+		},
+	})
+
+	blocked, err := api.BlockedIDs(context.Background())
+	if err != nil {
+		t.Fatalf("BlockedIDs: %s", err)
+	}
+	muted, err := api.MutedIDs(context.Background())
+	if err != nil {
+		t.Fatalf("MutedIDs: %s", err)
+	}
+
+	if fmt.Sprint(blocked) != "[1 2 3]" || fmt.Sprint(muted) != "[1 2 3]" {
+		t.Errorf("got blocked %v muted %v expected [1 2 3]", blocked, muted)
+	}
+	expected := "[/1.1/blocks/ids.json /1.1/blocks/ids.json " +
+		"/1.1/mutes/users/ids.json /1.1/mutes/users/ids.json]"
+	if fmt.Sprint(paths) != expected {
+		t.Errorf("requested %v expected %s", paths, expected)
+	}
+}
+
+func TestKeywordMutes(t *testing.T) {
+	var form url.Values
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			req.ParseForm()
+			form = req.PostForm
+			switch {
+			case strings.HasSuffix(req.URL.Path, "/keywords/create.json"):
+				return `{"id":"7","keyword":"spoilers","mute_surfaces":["home_timeline"]}`
+			case strings.HasSuffix(req.URL.Path, "/keywords/list.json"):
+				return `{"muted_keywords":[{"id":"7","keyword":"spoilers"},{"id":"8","keyword":"#nba"}]}`
+			}
+			return ""
+		},
+	})
+
+	muted, err := api.MuteKeyword(context.Background(), "spoilers")
+	if err != nil {
+		t.Fatalf("MuteKeyword: %s", err)
+	}
+	if muted.GetId() != "7" || fmt.Sprint(muted.GetMuteSurfaces()) != "[home_timeline]" ||
+		form.Get("keyword") != "spoilers" {
+		t.Errorf("MuteKeyword: got %s %v after sending %v", muted.GetId(),
+			muted.GetMuteSurfaces(), form)
+	}
+
+	keywords, err := api.MutedKeywords(context.Background())
+	if err != nil {
+		t.Fatalf("MutedKeywords: %s", err)
+	}
+	if len(keywords) != 2 || keywords[1].GetKeyword() != "#nba" {
+		t.Errorf("MutedKeywords: got %v", keywords)
+	}
+
+	if err = api.UnmuteKeywords(context.Background(), "7", "8"); err != nil {
+		t.Fatalf("UnmuteKeywords: %s", err)
+	}
+	if form.Get("ids") != "7,8" {
+		t.Errorf("UnmuteKeywords: sent %v", form)
+	}
+}
+
+func TestBlockErrors(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 404,
+		body:   `{"errors":[{"code":34,"message":"Sorry, that page does not exist."}]}`,
+	})
+
+	_, err := api.Block(context.Background(), ByScreenName("gone"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Block: got %v expected ErrNotFound", err)
+	}
+
+	var terr *TwitterError
+	api = newFakeApi(&tFakeTransport{
+		status: 403,
+		body:   `{"errors":[{"code":326,"message":"To protect our users from spam..."}]}`,
+	})
+	if _, err = api.Mute(context.Background(), ByID(1)); !errors.As(err, &terr) ||
+		terr.GetStatusCode() != 403 || terr.GetCode() != 326 {
+		t.Errorf("Mute: got %v expected a 403 with code 326", err)
+	}
+}
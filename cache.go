@@ -0,0 +1,391 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const kDefaultCacheSize = 1024
+
+// A cached GET response
+type CacheEntry struct {
+	Body []byte
+
+	// Validators sent back in conditional requests once the entry is stale
+	ETag         string
+	LastModified string
+
+	// The entry is used without asking Twitter until then
+	Expires time.Time
+}
+
+// Stores GET responses, see Api.SetCache. Implementations must be safe for
+// concurrent use. Entries are never modified after Set.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+
+	// Returns the keys of all entries, the Api looks through them for the
+	// entries a write made stale
+	Keys() []string
+}
+
+// A Cache in memory dropping the least recently used entries beyond its
+// size. Every Api starts out with one holding 1024 entries.
+type LRUCache struct {
+	size    int
+	lock    sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type tLRUItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// Creates an LRUCache holding up to size entries
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (self *LRUCache) Get(key string) (*CacheEntry, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	element, ok := self.entries[key]
+	if !ok {
+		return nil, false
+	}
+	self.order.MoveToFront(element)
+	return element.Value.(*tLRUItem).entry, true
+}
+
+func (self *LRUCache) Set(key string, entry *CacheEntry) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if element, ok := self.entries[key]; ok {
+		element.Value.(*tLRUItem).entry = entry
+		self.order.MoveToFront(element)
+		return
+	}
+
+	self.entries[key] = self.order.PushFront(&tLRUItem{key, entry})
+	for self.order.Len() > self.size {
+		oldest := self.order.Back()
+		self.order.Remove(oldest)
+		delete(self.entries, oldest.Value.(*tLRUItem).key)
+	}
+}
+
+func (self *LRUCache) Delete(key string) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if element, ok := self.entries[key]; ok {
+		self.order.Remove(element)
+		delete(self.entries, key)
+	}
+}
+
+func (self *LRUCache) Keys() []string {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	keys := make([]string, 0, len(self.entries))
+	for key := range self.entries {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// A Cache keeping one JSON file per entry in a directory, so entries
+// survive restarts. Nothing is ever evicted, clear the directory to make
+// room. Keys reads every file, which makes writes through the Api slower
+// the more entries there are.
+type DiskCache struct {
+	dir string
+}
+
+// An entry as a DiskCache stores it, with the key its file name hashes
+type tDiskEntry struct {
+	Key string `json:"key"`
+	CacheEntry
+}
+
+// Creates a DiskCache in dir, creating the directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir}, nil
+}
+
+// Keys contain URLs, hash them into safe file names
+func (self *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(self.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (self *DiskCache) read(name string) (*tDiskEntry, bool) {
+	entry := new(tDiskEntry)
+
+	data, err := ioutil.ReadFile(name)
+	if err != nil || json.Unmarshal(data, entry) != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (self *DiskCache) Get(key string) (*CacheEntry, bool) {
+	entry, ok := self.read(self.path(key))
+	if !ok || entry.Key != key {
+		return nil, false
+	}
+	return &entry.CacheEntry, true
+}
+
+// Failing to write only costs a request later, so errors are dropped
+func (self *DiskCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(&tDiskEntry{key, *entry})
+	if err != nil {
+		return
+	}
+
+	name := self.path(key)
+	if ioutil.WriteFile(name+".tmp", data, 0600) == nil {
+		os.Rename(name+".tmp", name)
+	}
+}
+
+func (self *DiskCache) Delete(key string) { os.Remove(self.path(key)) }
+
+func (self *DiskCache) Keys() []string {
+	var keys []string
+
+	names, _ := filepath.Glob(filepath.Join(self.dir, "*.json"))
+	for _, name := range names {
+		if entry, ok := self.read(name); ok {
+			keys = append(keys, entry.Key)
+		}
+	}
+	return keys
+}
+
+// Write endpoints mapped to the endpoints whose cached responses they may
+// change. Writes to other endpoints drop every cached response.
+var kCacheInvalidations = map[string][]string{
+	"statuses":        {"statuses", "search", "users", "favorites"},
+	"favorites":       {"favorites", "statuses"},
+	"friendships":     {"friendships", "friends", "followers", "users"},
+	"blocks":          {"blocks", "friendships", "followers", "friends", "users"},
+	"mutes":           {"mutes", "friendships"},
+	"lists":           {"lists"},
+	"account":         {"account", "users"},
+	"direct_messages": {"direct_messages"},
+	"saved_searches":  {"saved_searches"},
+}
+
+// Replaces the cache of GET responses, an LRUCache by default. nil turns
+// caching off. Only endpoints given a TTL are cached, by default
+// "users/show" and "statuses/show" for a minute, see SetCacheTTL.
+func (self *Api) SetCache(cache Cache) {
+	self.cacheLock.Lock()
+	defer self.cacheLock.Unlock()
+
+	self.cache = cache
+}
+
+// Sets how long responses of an endpoint are used without asking Twitter
+// again. endpoint is the path without version and extension, such as
+// "users/show", and also matches the paths below it. Stale responses with
+// an ETag or Last-Modified header are revalidated with a conditional
+// request. Endpoints without a TTL, or a TTL of 0, aren't cached at all.
+//
+// Successful writes through the Api drop the cached responses they may
+// change, writes made elsewhere only show once the responses expire.
+func (self *Api) SetCacheTTL(endpoint string, ttl time.Duration) {
+	self.cacheLock.Lock()
+	defer self.cacheLock.Unlock()
+
+	self.cacheTTLs[strings.Trim(endpoint, "/")] = ttl
+}
+
+func (self *Api) initCache() {
+	self.cache = NewLRUCache(kDefaultCacheSize)
+	self.cacheTTLs = map[string]time.Duration{
+		"users/show":    time.Minute,
+		"statuses/show": time.Minute,
+	}
+}
+
+func (self *Api) getCache() Cache {
+	self.cacheLock.Lock()
+	defer self.cacheLock.Unlock()
+
+	return self.cache
+}
+
+// Returns the path of u without version and extension, as endpoints are
+// given to SetCacheTTL
+func cachePath(u *url.URL) string {
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".json")
+	return strings.TrimPrefix(path, "1.1/")
+}
+
+// Whether path is endpoint or below it
+func underEndpoint(path, endpoint string) bool {
+	return path == endpoint || strings.HasPrefix(path, endpoint+"/")
+}
+
+// Returns the TTL of the longest endpoint matching u
+func (self *Api) cacheTTL(u *url.URL) time.Duration {
+	self.cacheLock.Lock()
+	defer self.cacheLock.Unlock()
+
+	path := cachePath(u)
+	var ttl time.Duration
+	longest := -1
+	for endpoint, endpointTTL := range self.cacheTTLs {
+		if len(endpoint) > longest && underEndpoint(path, endpoint) {
+			ttl, longest = endpointTTL, len(endpoint)
+		}
+	}
+	return ttl
+}
+
+// Drops the cached responses a successful write to u may have changed
+func (self *Api) invalidate(u *url.URL) {
+	cache := self.getCache()
+	if cache == nil {
+		return
+	}
+
+	affected, known := kCacheInvalidations[strings.SplitN(cachePath(u), "/", 2)[0]]
+	for _, key := range cache.Keys() {
+		drop := !known
+		if cached, ok := keyUrl(key); ok {
+			for _, endpoint := range affected {
+				drop = drop || underEndpoint(cachePath(cached), endpoint)
+			}
+		}
+		if drop {
+			cache.Delete(key)
+		}
+	}
+}
+
+// Keys entries by the credentials and the URL with its query sorted, so
+// users never see each other's responses
+func (self *Api) cacheKey(req *http.Request) string {
+	user, pass := self.requestCredentials(req.Context())
+	identity := sha256.Sum256([]byte(user + ":" + pass))
+	u := req.URL
+
+	normal := *u
+	normal.Scheme = strings.ToLower(u.Scheme)
+	normal.Host = strings.ToLower(u.Host)
+	normal.RawQuery = u.Query().Encode()
+	normal.Fragment = ""
+
+	return hex.EncodeToString(identity[:8]) + " " + normal.String()
+}
+
+// Returns the URL a key of cacheKey was made of
+func keyUrl(key string) (*url.URL, bool) {
+	fields := strings.SplitN(key, " ", 2)
+	if len(fields) != 2 {
+		return nil, false
+	}
+	u, err := url.Parse(fields[1])
+	return u, err == nil
+}
+
+// Sends a GET request through the cache and returns the response body
+func (self *Api) cachedGet(cache Cache, req *http.Request) ([]byte, error) {
+	key := self.cacheKey(req)
+	ttl := self.cacheTTL(req.URL)
+	now := time.Now()
+
+	// Nothing is stored without a TTL, not even for revalidation, so
+	// responses of private endpoints don't end up in a DiskCache
+	if ttl <= 0 {
+		return self.send(req)
+	}
+
+	entry, cached := cache.Get(key)
+	if cached && now.Before(entry.Expires) {
+		return entry.Body, nil
+	}
+	if cached {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	r, err := self.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && r.StatusCode == http.StatusNotModified {
+		r.Body.Close()
+		fresh := *entry
+		fresh.Expires = now.Add(ttl)
+		cache.Set(key, &fresh)
+		return fresh.Body, nil
+	}
+
+	if err = CheckResponse(r); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := &CacheEntry{
+		Body:         data,
+		ETag:         r.Header.Get("ETag"),
+		LastModified: r.Header.Get("Last-Modified"),
+		Expires:      now.Add(ttl),
+	}
+	cache.Set(key, fresh)
+
+	return data, nil
+}
@@ -0,0 +1,199 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	requests := 0
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"id":1,"screen_name":"jb55"}`,
+		check:  func(req *http.Request) { requests++ },
+	})
+
+	for i := 0; i < 3; i++ {
+		if user := <-api.GetUser(ByScreenName("jb55")); user.GetId() != 1 {
+			t.Fatalf("GetUser: got id %d", user.GetId())
+		}
+	}
+	if requests != 1 {
+		t.Errorf("sent %d requests expected 1", requests)
+	}
+
+	// Other credentials, other entries
+	api.SetCredentials("other", "secret")
+	<-api.GetUser(ByScreenName("jb55"))
+	if requests != 2 {
+		t.Errorf("sent %d requests expected 2", requests)
+	}
+
+	api.SetCache(nil)
+	<-api.GetUser(ByScreenName("jb55"))
+	if requests != 3 {
+		t.Errorf("sent %d requests expected 3 without a cache", requests)
+	}
+}
+
+func TestCacheRevalidates(t *testing.T) {
+	var conditional []string
+	transport := &tFakeTransport{header: http.Header{"Etag": {`"v1"`}}}
+	transport.reply = func(req *http.Request) string {
+		conditional = append(conditional, req.Header.Get("If-None-Match"))
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			transport.status = http.StatusNotModified
+			return ""
+		}
+		transport.status = 200
+		return `[{"id":2}]`
+	}
+	api := newFakeApi(transport)
+	api.SetCacheTTL("statuses/mentions", time.Nanosecond)
+
+	for i := 0; i < 2; i++ {
+		statuses, err := api.GetMentions(context.Background(), TimelineOptions{Count: 5})
+		if err != nil {
+			t.Fatalf("GetMentions: %s", err)
+		}
+		if len(statuses) != 1 || statuses[0].GetId() != 2 {
+			t.Errorf("got %d statuses", len(statuses))
+		}
+	}
+	if len(conditional) != 2 || conditional[0] != "" || conditional[1] != `"v1"` {
+		t.Errorf("got If-None-Match headers %q", conditional)
+	}
+}
+
+func TestCacheSkipsEndpointsWithoutTTL(t *testing.T) {
+	cache := NewLRUCache(16)
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `[{"id":2}]`,
+		header: http.Header{"Etag": {`"v1"`}},
+	})
+	api.SetCache(cache)
+
+	if _, err := api.GetMentions(context.Background(), TimelineOptions{}); err != nil {
+		t.Fatalf("GetMentions: %s", err)
+	}
+	if keys := cache.Keys(); len(keys) != 0 {
+		t.Errorf("stored %q expected nothing", keys)
+	}
+}
+
+func TestCacheDroppedAfterWrites(t *testing.T) {
+	requests := 0
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"id":1,"screen_name":"jb55"}`,
+		check: func(req *http.Request) {
+			if req.Method == "GET" {
+				requests++
+			}
+		},
+	})
+	api.SetCacheTTL("lists", time.Hour)
+	ctx := context.Background()
+
+	get := func(rawUrl string) {
+		if _, err := api.getJson(ctx, rawUrl); err != nil {
+			t.Fatalf("getJson: %s", err)
+		}
+	}
+	get("https://api.twitter.com/1.1/users/show.json?screen_name=jb55")
+	get("https://api.twitter.com/1.1/lists/list.json")
+
+	// Following changes the user, not the lists
+	if err := api.callJson(ctx, "POST", _QUERY_FOLLOW, nil, nil); err != nil {
+		t.Fatalf("POST: %s", err)
+	}
+	get("https://api.twitter.com/1.1/users/show.json?screen_name=jb55")
+	get("https://api.twitter.com/1.1/lists/list.json")
+	if requests != 3 {
+		t.Errorf("sent %d requests expected 3", requests)
+	}
+
+	// Unknown writes drop everything
+	if err := api.callJson(ctx, "POST", "https://api.twitter.com/1.1/x/y.json", nil, nil); err != nil {
+		t.Fatalf("POST: %s", err)
+	}
+	get("https://api.twitter.com/1.1/lists/list.json")
+	if requests != 4 {
+		t.Errorf("sent %d requests expected 4", requests)
+	}
+}
+
+func TestCacheTTLMatchesLongestEndpoint(t *testing.T) {
+	api := NewApi()
+	api.SetCacheTTL("lists", time.Hour)
+	api.SetCacheTTL("lists/members", time.Second)
+
+	tests := map[string]time.Duration{
+		"https://api.twitter.com/1.1/lists/members.json?list_id=1": time.Second,
+		"https://api.twitter.com/1.1/lists/statuses.json":          time.Hour,
+		"http://twitter.com/users/show/jb55.json":                  time.Minute,
+		"https://api.twitter.com/1.1/listsx.json":                  0,
+	}
+	for rawUrl, ttl := range tests {
+		req, _ := http.NewRequest("GET", rawUrl, nil)
+		if got := api.cacheTTL(req.URL); got != ttl {
+			t.Errorf("cacheTTL(%s): got %s expected %s", rawUrl, got, ttl)
+		}
+	}
+}
+
+func TestLRUCacheEvicts(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", &CacheEntry{Body: []byte("a")})
+	cache.Set("b", &CacheEntry{Body: []byte("b")})
+	cache.Get("a")
+	cache.Set("c", &CacheEntry{Body: []byte("c")})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("least recently used entry kept")
+	}
+	if entry, ok := cache.Get("a"); !ok || string(entry.Body) != "a" {
+		t.Errorf("recently used entry dropped")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskCache: %s", err)
+	}
+
+	expires := time.Now().Add(time.Minute).Round(0)
+	cache.Set("k https://x/y?a=1", &CacheEntry{Body: []byte("{}"), ETag: "e", Expires: expires})
+	entry, ok := cache.Get("k https://x/y?a=1")
+	if !ok || entry.ETag != "e" || !entry.Expires.Equal(expires) {
+		t.Fatalf("Get: got %+v", entry)
+	}
+
+	if keys := cache.Keys(); len(keys) != 1 || keys[0] != "k https://x/y?a=1" {
+		t.Errorf("Keys: got %q", keys)
+	}
+
+	cache.Delete("k https://x/y?a=1")
+	if _, ok = cache.Get("k https://x/y?a=1"); ok {
+		t.Errorf("Get after Delete found the entry")
+	}
+}
@@ -0,0 +1,201 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Package churn tracks who follows and unfollows an account over time. It
+// stores periodic snapshots of the follower ids and turns the differences
+// between consecutive snapshots into events.
+//
+//    store, _ := churn.NewFileStore("followers/jb55")
+//    tracker := churn.NewTracker(api, twitter.ByScreenName("jb55"), store)
+//    events, err := tracker.Update(ctx)
+//
+package churn
+
+import (
+	"context"
+	"time"
+
+	"twitter"
+)
+
+type EventType int
+
+const (
+	Follow EventType = iota
+	Unfollow
+)
+
+func (self EventType) String() string {
+	if self == Follow {
+		return "follow"
+	}
+	return "unfollow"
+}
+
+// A user that started or stopped following the tracked account somewhere
+// between Since and Time
+type Event struct {
+	Type   EventType
+	UserId int64
+
+	// nil if the user couldn't be looked up, e.g. because the account was
+	// suspended or deleted
+	User twitter.User
+
+	Since time.Time
+	Time  time.Time
+}
+
+// What a Tracker needs from the API, *twitter.Api satisfies it
+type Source interface {
+	FollowerIDs(ctx context.Context, user twitter.UserRef) ([]int64, error)
+	LookupUsers(ctx context.Context, users []twitter.UserRef) ([]twitter.User, error)
+}
+
+// Takes follower snapshots of one account
+type Tracker struct {
+	api   Source
+	user  twitter.UserRef
+	store Store
+
+	// Returns the time snapshots are taken at, time.Now by default
+	Now func() time.Time
+}
+
+func NewTracker(api Source, user twitter.UserRef, store Store) *Tracker {
+	return &Tracker{api: api, user: user, store: store, Now: time.Now}
+}
+
+// Takes and stores a new snapshot and returns the events since the
+// previous one. The first snapshot produces no events. The snapshot is
+// only stored once its events were resolved, so a failed update loses
+// nothing and the next one reports its events.
+func (self *Tracker) Update(ctx context.Context) ([]Event, error) {
+	var events []Event
+
+	previous, err := self.store.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := self.api.FollowerIDs(ctx, self.user)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Taken: self.Now(), Ids: ids}
+	if previous != nil {
+		if events, err = self.resolve(ctx, Diff(previous, snapshot)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = self.store.Save(snapshot); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Returns the events between every pair of consecutive stored snapshots,
+// oldest first
+func (self *Tracker) History(ctx context.Context) ([]Event, error) {
+	var events []Event
+
+	snapshots, err := self.store.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(snapshots); i++ {
+		events = append(events, Diff(snapshots[i-1], snapshots[i])...)
+	}
+	return self.resolve(ctx, events)
+}
+
+// Calls Update every interval and sends the events on events until ctx
+// is done or an update fails
+func (self *Tracker) Run(ctx context.Context, interval time.Duration, events chan<- Event) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		batch, err := self.Update(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range batch {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Returns the follow and unfollow events between two snapshots, without
+// looking up the users. New followers come first, both in id order.
+func Diff(previous, next *Snapshot) []Event {
+	var events []Event
+	before := twitter.NewIDSet(previous.Ids...)
+	after := twitter.NewIDSet(next.Ids...)
+
+	for _, id := range after.Difference(before).Slice() {
+		events = append(events, Event{Follow, id, nil, previous.Taken, next.Taken})
+	}
+	for _, id := range before.Difference(after).Slice() {
+		events = append(events, Event{Unfollow, id, nil, previous.Taken, next.Taken})
+	}
+
+	return events
+}
+
+// Fills in the users of the events with a batched lookup
+func (self *Tracker) resolve(ctx context.Context, events []Event) ([]Event, error) {
+	if len(events) == 0 {
+		return events, nil
+	}
+
+	refs := make([]twitter.UserRef, 0, len(events))
+	seen := twitter.NewIDSet()
+	for _, event := range events {
+		if !seen.Has(event.UserId) {
+			seen.Add(event.UserId)
+			refs = append(refs, twitter.ByID(event.UserId))
+		}
+	}
+
+	users, err := self.api.LookupUsers(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[int64]twitter.User, len(users))
+	for _, user := range users {
+		byId[user.GetId()] = user
+	}
+	for i := range events {
+		events[i].User = byId[events[i].UserId]
+	}
+
+	return events, nil
+}
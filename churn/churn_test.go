@@ -0,0 +1,148 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package churn
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"twitter"
+)
+
+// Serves follower ids from a list of snapshots, one per call
+type tFakeSource struct {
+	followers [][]int64
+	lookups   int
+
+	// Returned by the next LookupUsers call
+	lookupErr error
+}
+
+func (self *tFakeSource) FollowerIDs(ctx context.Context, user twitter.UserRef) ([]int64, error) {
+	ids := self.followers[0]
+	self.followers = self.followers[1:]
+	return ids, nil
+}
+
+func (self *tFakeSource) LookupUsers(ctx context.Context, users []twitter.UserRef) ([]twitter.User, error) {
+	var found []twitter.User
+	self.lookups++
+	if err := self.lookupErr; err != nil {
+		self.lookupErr = nil
+		return nil, err
+	}
+	for _, ref := range users {
+		// 4 is suspended
+		if ref.GetId() != 4 {
+			user, _ := twitter.ParseUser([]byte(`{"id":` + ref.String() + `}`))
+			found = append(found, user)
+		}
+	}
+	return found, nil
+}
+
+func TestTrackerUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "churn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %s", err)
+	}
+
+	source := &tFakeSource{followers: [][]int64{{1, 2, 4}, {2, 3}, {2, 3, 5}}}
+	tracker := NewTracker(source, twitter.ByScreenName("jb55"), store)
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tracker.Now = func() time.Time {
+		day = day.Add(24 * time.Hour)
+		return day
+	}
+
+	if events, err := tracker.Update(context.Background()); err != nil || len(events) != 0 {
+		t.Fatalf("first Update: got %v, %v expected no events", events, err)
+	}
+
+	events, err := tracker.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update: %s", err)
+	}
+
+	expected := []struct {
+		typ      EventType
+		id       int64
+		resolved bool
+	}{{Follow, 3, true}, {Unfollow, 1, true}, {Unfollow, 4, false}}
+
+	if len(events) != len(expected) {
+		t.Fatalf("got %d events expected %d", len(events), len(expected))
+	}
+	for i, e := range expected {
+		event := events[i]
+		if event.Type != e.typ || event.UserId != e.id || (event.User != nil) != e.resolved {
+			t.Errorf("event %d: got %s %d expected %s %d", i, event.Type,
+				event.UserId, e.typ, e.id)
+		}
+		if !event.Time.Equal(day) || !event.Since.Equal(day.Add(-24*time.Hour)) {
+			t.Errorf("event %d: happened between %s and %s", i, event.Since, event.Time)
+		}
+	}
+
+	tracker.Update(context.Background())
+	history, err := tracker.History(context.Background())
+	if err != nil {
+		t.Fatalf("History: %s", err)
+	}
+	if len(history) != 4 || history[3].UserId != 5 {
+		t.Errorf("History: got %v", history)
+	}
+}
+
+func TestTrackerUpdateKeepsEventsOfFailedLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "churn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %s", err)
+	}
+
+	source := &tFakeSource{followers: [][]int64{{1}, {1, 2}, {1, 2}}}
+	tracker := NewTracker(source, twitter.ByScreenName("jb55"), store)
+	tracker.Update(context.Background())
+
+	source.lookupErr = errors.New("over capacity")
+	if _, err := tracker.Update(context.Background()); err == nil {
+		t.Fatalf("Update: got no error expected the lookup error")
+	}
+
+	events, err := tracker.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update: %s", err)
+	}
+	if len(events) != 1 || events[0].Type != Follow || events[0].UserId != 2 {
+		t.Errorf("got %v expected the follow of 2 again", events)
+	}
+}
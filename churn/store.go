@@ -0,0 +1,127 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package churn
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const kSnapshotTimeFormat = "20060102T150405.000000000Z"
+
+// The follower ids of an account at one point in time
+type Snapshot struct {
+	Taken time.Time `json:"taken"`
+	Ids   []int64   `json:"ids"`
+}
+
+// Keeps the snapshots of one account
+type Store interface {
+	Save(snapshot *Snapshot) error
+
+	// Returns the most recent snapshot, nil if there is none yet
+	Latest() (*Snapshot, error)
+
+	// Returns every snapshot, oldest first
+	Snapshots() ([]*Snapshot, error)
+}
+
+// A Store keeping one JSON file per snapshot in a directory. Use one
+// directory per tracked account.
+type FileStore struct {
+	dir string
+}
+
+// Creates a FileStore in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir}, nil
+}
+
+// Writes the snapshot to a temporary file first so a crash never leaves
+// a half written snapshot behind
+func (self *FileStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(self.dir, snapshot.Taken.UTC().Format(kSnapshotTimeFormat)+".json")
+	if err = ioutil.WriteFile(name+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+func (self *FileStore) Latest() (*Snapshot, error) {
+	names, err := self.names()
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+	return self.load(names[len(names)-1])
+}
+
+func (self *FileStore) Snapshots() ([]*Snapshot, error) {
+	names, err := self.names()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*Snapshot, len(names))
+	for i, name := range names {
+		if snapshots[i], err = self.load(name); err != nil {
+			return nil, err
+		}
+	}
+	return snapshots, nil
+}
+
+// Returns the snapshot file names, their timestamps sort them oldest first
+func (self *FileStore) names() ([]string, error) {
+	entries, err := ioutil.ReadDir(self.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (self *FileStore) load(name string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(filepath.Join(self.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := new(Snapshot)
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,265 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type DirectMessage interface {
+	GetId() int64
+	GetCreatedAt() time.Time
+	GetSenderId() int64
+	GetRecipientId() int64
+	GetText() string
+	GetEntities() Entities
+	// The options offered to the recipient, if any
+	GetQuickReplyOptions() []QuickReplyOption
+	// The metadata of the option chosen if this message answers a
+	// quick reply
+	GetQuickReplyResponse() string
+	// The id of the attached media, 0 if there is none
+	GetMediaId() int64
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+// An option offered to the recipient of a direct message. Metadata is
+// sent back with the answer but not shown.
+type QuickReplyOption struct {
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	Metadata    string `json:"metadata,omitempty"`
+}
+
+// A direct message to send, see SendDirectMessage
+type OutgoingDirectMessage struct {
+	RecipientId int64
+	Text        string
+
+	// Media uploaded with the dm_image, dm_gif or dm_video category
+	MediaId int64
+
+	QuickReplyOptions []QuickReplyOption
+}
+
+type tTwitterQuickReply struct {
+	Type     string             `json:"type"`
+	Options  []QuickReplyOption `json:"options,omitempty"`
+	Metadata string             `json:"metadata,omitempty"`
+}
+
+type tTwitterAttachment struct {
+	Type  string `json:"type"`
+	Media struct {
+		Id int64 `json:"id,string"`
+	} `json:"media"`
+}
+
+type tTwitterMessageData struct {
+	Text                 string              `json:"text"`
+	Entities             *Entities           `json:"entities,omitempty"`
+	Quick_reply          *tTwitterQuickReply `json:"quick_reply,omitempty"`
+	Quick_reply_response *tTwitterQuickReply `json:"quick_reply_response,omitempty"`
+	Attachment           *tTwitterAttachment `json:"attachment,omitempty"`
+}
+
+// Direct messages are message_create events
+type tTwitterDirectMessage struct {
+	Type              string `json:"type"`
+	Id                int64  `json:"id,string,omitempty"`
+	Created_timestamp int64  `json:"created_timestamp,string,omitempty"`
+	Message_create    struct {
+		Target struct {
+			Recipient_id int64 `json:"recipient_id,string"`
+		} `json:"target"`
+		Sender_id    int64               `json:"sender_id,string,omitempty"`
+		Message_data tTwitterMessageData `json:"message_data"`
+	} `json:"message_create"`
+	tJsonFields
+}
+
+type tTwitterDirectMessageDummy struct {
+	Event tTwitterDirectMessage `json:"event"`
+}
+
+type tTwitterDirectMessageList struct {
+	Events      []tTwitterDirectMessage
+	Next_cursor string
+}
+
+func (self *tTwitterDirectMessage) UnmarshalJSON(data []byte) error {
+	type plain tTwitterDirectMessage
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterDirectMessage) MarshalJSON() ([]byte, error) {
+	type plain tTwitterDirectMessage
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterDirectMessage) GetId() int64 { return self.Id }
+
+func (self *tTwitterDirectMessage) GetCreatedAt() time.Time {
+	return time.Unix(0, self.Created_timestamp*int64(time.Millisecond))
+}
+
+func (self *tTwitterDirectMessage) GetSenderId() int64 {
+	return self.Message_create.Sender_id
+}
+
+func (self *tTwitterDirectMessage) GetRecipientId() int64 {
+	return self.Message_create.Target.Recipient_id
+}
+
+func (self *tTwitterDirectMessage) GetText() string {
+	return self.Message_create.Message_data.Text
+}
+
+func (self *tTwitterDirectMessage) GetEntities() Entities {
+	if entities := self.Message_create.Message_data.Entities; entities != nil {
+		return *entities
+	}
+	return Entities{}
+}
+
+func (self *tTwitterDirectMessage) GetQuickReplyOptions() []QuickReplyOption {
+	if reply := self.Message_create.Message_data.Quick_reply; reply != nil {
+		return reply.Options
+	}
+	return nil
+}
+
+func (self *tTwitterDirectMessage) GetQuickReplyResponse() string {
+	if response := self.Message_create.Message_data.Quick_reply_response; response != nil {
+		return response.Metadata
+	}
+	return ""
+}
+
+func (self *tTwitterDirectMessage) GetMediaId() int64 {
+	if attachment := self.Message_create.Message_data.Attachment; attachment != nil {
+		return attachment.Media.Id
+	}
+	return 0
+}
+
+// Returns a page of the direct messages the authenticated user received
+// in the last 30 days, newest first.
+//
+// Received and sent messages are paged together, so a page may hold
+// fewer than count messages.
+//
+// cursor:
+//  The cursor returned with the previous page, "" for the first page
+// count:
+//  The number of messages to fetch per page, at most 50. Set to 0 to use
+//  the default value.
+//
+// Returns the messages and the cursor of the next page, "" on the last page
+func (self *Api) GetDirectMessages(ctx context.Context, cursor string,
+	count int) ([]DirectMessage, string, error) {
+	return self.getDirectMessages(ctx, cursor, count, false)
+}
+
+// Like GetDirectMessages, but returns the messages the authenticated user
+// sent
+func (self *Api) GetSentDirectMessages(ctx context.Context, cursor string,
+	count int) ([]DirectMessage, string, error) {
+	return self.getDirectMessages(ctx, cursor, count, true)
+}
+
+func (self *Api) getDirectMessages(ctx context.Context, cursor string, count int,
+	sent bool) ([]DirectMessage, string, error) {
+	var list tTwitterDirectMessageList
+	var messages []DirectMessage
+	params := url.Values{}
+
+	me, err := self.authenticatedUserId(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	if count > 0 {
+		params.Set("count", strconv.Itoa(count))
+	}
+
+	if err = self.callJson(ctx, "GET", _QUERY_DIRECTMESSAGES, params, &list); err != nil {
+		return nil, "", err
+	}
+
+	for i := range list.Events {
+		message := &list.Events[i]
+		if (message.GetSenderId() == me) == sent {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, list.Next_cursor, nil
+}
+
+// Returns a single direct message sent or received by the authenticated
+// user
+func (self *Api) GetDirectMessage(ctx context.Context, id int64) (DirectMessage, error) {
+	var message tTwitterDirectMessageDummy
+
+	err := self.callJson(ctx, "GET", _QUERY_SHOWDIRECTMESSAGE, idParams(id), &message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message.Event, nil
+}
+
+// Sends a direct message from the authenticated user and returns it
+func (self *Api) SendDirectMessage(ctx context.Context, outgoing OutgoingDirectMessage) (DirectMessage, error) {
+	var message tTwitterDirectMessageDummy
+	var request tTwitterDirectMessageDummy
+
+	event := &request.Event
+	event.Type = "message_create"
+	event.Message_create.Target.Recipient_id = outgoing.RecipientId
+	event.Message_create.Message_data.Text = outgoing.Text
+	if outgoing.MediaId != 0 {
+		attachment := &tTwitterAttachment{Type: "media"}
+		attachment.Media.Id = outgoing.MediaId
+		event.Message_create.Message_data.Attachment = attachment
+	}
+	if len(outgoing.QuickReplyOptions) > 0 {
+		event.Message_create.Message_data.Quick_reply = &tTwitterQuickReply{
+			Type:    "options",
+			Options: outgoing.QuickReplyOptions,
+		}
+	}
+
+	if err := self.postJson(ctx, _QUERY_NEWDIRECTMESSAGE, &request, &message); err != nil {
+		return nil, err
+	}
+
+	return &message.Event, nil
+}
+
+// Deletes a direct message. It's only removed for the authenticated user,
+// the other side still sees it.
+func (self *Api) DestroyDirectMessage(ctx context.Context, id int64) error {
+	return self.callJson(ctx, "DELETE", _QUERY_DESTROYDIRECTMESSAGE, idParams(id), nil)
+}
@@ -0,0 +1,143 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const kDirectMessageEvent = `{"type":"message_create","id":"110",` +
+	`"created_timestamp":"1517359238930","message_create":{` +
+	`"target":{"recipient_id":"2"},"sender_id":"%s","message_data":{` +
+	`"text":"pick one #go","entities":{"hashtags":[{"text":"go","indices":[9,12]}]},` +
+	`"quick_reply":{"type":"options","options":[{"label":"Yes","metadata":"y"}]}}}}`
+
+func TestGetDirectMessagesSplitsReceivedAndSent(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			if strings.HasSuffix(req.URL.Path, "/verify_credentials.json") {
+				return `{"id":1,"screen_name":"jb55"}`
+			}
+			if req.URL.Query().Get("cursor") != "abc" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+			return `{"next_cursor":"def","events":[` +
+				strings.Replace(kDirectMessageEvent, "%s", "3", 1) + "," +
+				strings.Replace(kDirectMessageEvent, "%s", "1", 1) + "]}"
+		},
+	})
+
+	received, next, err := api.GetDirectMessages(context.Background(), "abc", 0)
+	if err != nil {
+		t.Fatalf("GetDirectMessages: %s", err)
+	}
+	if next != "def" || len(received) != 1 || received[0].GetSenderId() != 3 {
+		t.Fatalf("got %d messages and cursor %q", len(received), next)
+	}
+
+	message := received[0]
+	if message.GetId() != 110 || message.GetRecipientId() != 2 ||
+		message.GetText() != "pick one #go" {
+		t.Errorf("got message %d to %d: %q", message.GetId(),
+			message.GetRecipientId(), message.GetText())
+	}
+	if message.GetCreatedAt().Unix() != 1517359238 {
+		t.Errorf("GetCreatedAt: got %s", message.GetCreatedAt())
+	}
+	if tags := message.GetEntities().Hashtags; len(tags) != 1 || tags[0].Text != "go" {
+		t.Errorf("GetEntities: got %v", message.GetEntities())
+	}
+	if options := message.GetQuickReplyOptions(); len(options) != 1 || options[0].Metadata != "y" {
+		t.Errorf("GetQuickReplyOptions: got %v", options)
+	}
+
+	sent, _, _ := api.GetSentDirectMessages(context.Background(), "abc", 0)
+	if len(sent) != 1 || sent[0].GetSenderId() != 1 {
+		t.Errorf("GetSentDirectMessages: got %v", sent)
+	}
+}
+
+func TestSendDirectMessage(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"event":` + strings.Replace(kDirectMessageEvent, "%s", "1", 1) + `}`,
+		check: func(req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			expected := `{"event":{"message_create":{"target":{"recipient_id":"2"},` +
+				`"message_data":{"text":"pick one","quick_reply":{"type":"options",` +
+				`"options":[{"label":"Yes","metadata":"y"}]},` +
+				`"attachment":{"type":"media","media":{"id":"7"}}}},"type":"message_create"}}`
+			if string(body) != expected {
+				t.Errorf("got body\n%s\nexpected\n%s", body, expected)
+			}
+		},
+	})
+
+	message, err := api.SendDirectMessage(context.Background(), OutgoingDirectMessage{
+		RecipientId:       2,
+		Text:              "pick one",
+		MediaId:           7,
+		QuickReplyOptions: []QuickReplyOption{{Label: "Yes", Metadata: "y"}},
+	})
+	if err != nil {
+		t.Fatalf("SendDirectMessage: %s", err)
+	}
+	if message.GetId() != 110 {
+		t.Errorf("GetId: got %d expected 110", message.GetId())
+	}
+}
+
+func TestAuthenticatedUserIdFollowsCredentials(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			user, _, _ := req.BasicAuth()
+			if user == "jb55" {
+				return `{"id":9918032,"screen_name":"jb55"}`
+			}
+			return `{"id":2,"screen_name":"` + user + `"}`
+		},
+	})
+
+	var wait sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wait.Add(2)
+		go func() {
+			defer wait.Done()
+			api.authenticatedUserId(context.Background())
+		}()
+		go func() {
+			defer wait.Done()
+			api.SetCredentials("rob", "secret")
+		}()
+	}
+	wait.Wait()
+
+	if id, err := api.authenticatedUserId(context.Background()); err != nil || id != 2 {
+		t.Errorf("authenticatedUserId: got %d, %v expected 2 for rob", id, err)
+	}
+
+	api.SetCredentials("jb55", "secret")
+	if id, _ := api.authenticatedUserId(context.Background()); id != 9918032 {
+		t.Errorf("authenticatedUserId after SetCredentials: got %d expected 9918032", id)
+	}
+}
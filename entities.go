@@ -0,0 +1,43 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+// The hashtags, links and mentions found in a text. Indices are the start
+// and end offsets of the entity in the text, counted in runes.
+type Entities struct {
+	Hashtags     []HashtagEntity `json:"hashtags"`
+	Urls         []UrlEntity     `json:"urls"`
+	UserMentions []MentionEntity `json:"user_mentions"`
+}
+
+type HashtagEntity struct {
+	Text    string `json:"text"`
+	Indices [2]int `json:"indices"`
+}
+
+type UrlEntity struct {
+	Url         string `json:"url"`
+	ExpandedUrl string `json:"expanded_url"`
+	DisplayUrl  string `json:"display_url"`
+	Indices     [2]int `json:"indices"`
+}
+
+type MentionEntity struct {
+	Id         int64  `json:"id"`
+	ScreenName string `json:"screen_name"`
+	Name       string `json:"name"`
+	Indices    [2]int `json:"indices"`
+}
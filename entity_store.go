@@ -0,0 +1,229 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// An identity map of users and statuses. With a store set on an Api, every
+// user and status the Api decodes, the authors of statuses and owners of
+// lists included, is merged into the object the store already has for its
+// id, so the same id always yields the same object and authors and statuses
+// stay linked across responses. The latest response wins, except that a
+// user keeps the newest status seen for them. Legacy search results and
+// relationships aren't users or statuses and aren't stored.
+//
+// Stored objects are updated in place under the store's lock, which their
+// getters hold while reading, so they may be read while API calls run.
+type EntityStore struct {
+	lock     sync.RWMutex
+	users    map[int64]*tTwitterUser
+	statuses map[int64]*tTwitterStatus
+	byUser   map[int64]map[int64]*tTwitterStatus
+}
+
+func NewEntityStore() *EntityStore {
+	return &EntityStore{
+		users:    make(map[int64]*tTwitterUser),
+		statuses: make(map[int64]*tTwitterStatus),
+		byUser:   make(map[int64]map[int64]*tTwitterStatus),
+	}
+}
+
+// Turns on merging decoded users and statuses into store, nil turns it
+// off again. Off by default.
+func (self *Api) SetEntityStore(store *EntityStore) { self.entities = store }
+
+// Returns the user with the given id, nil if none was seen
+func (self *EntityStore) User(id int64) User {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	if user, ok := self.users[id]; ok {
+		return user
+	}
+	return nil
+}
+
+// Returns the status with the given id, nil if none was seen
+func (self *EntityStore) Status(id int64) Status {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	if status, ok := self.statuses[id]; ok {
+		return status
+	}
+	return nil
+}
+
+// Returns the statuses seen from a user, newest first
+func (self *EntityStore) StatusesByUser(userId int64) []Status {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	statuses := make([]Status, 0, len(self.byUser[userId]))
+	for _, status := range self.byUser[userId] {
+		statuses = append(statuses, status)
+	}
+	// The getters take the lock as well, read the ids directly
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].(*tTwitterStatus).Id > statuses[j].(*tTwitterStatus).Id
+	})
+	return statuses
+}
+
+// The number of users and statuses in the store
+func (self *EntityStore) Len() (users, statuses int) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	return len(self.users), len(self.statuses)
+}
+
+func (self *EntityStore) addStatus(status *tTwitterStatus) *tTwitterStatus {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	return self.mergeStatus(status)
+}
+
+func (self *EntityStore) addUser(user *tTwitterUser) *tTwitterUser {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	return self.mergeUser(user, nil)
+}
+
+// Merges status and its author into the store, the store's lock must be
+// held. Returns the stored status.
+func (self *EntityStore) mergeStatus(status *tTwitterStatus) *tTwitterStatus {
+	if status == nil || status.Id == 0 || status.shared != nil {
+		return status
+	}
+
+	var author *tTwitterUser
+	if status.User != nil {
+		author = self.mergeUser(status.User, status)
+	}
+	canonical := self.putStatus(status, author)
+
+	// What GetUser does for statuses of no store
+	if author != nil && author.Id != 0 &&
+		(author.Status == nil || author.Status.Id < canonical.Id) {
+		author.Status = canonical
+	}
+	return canonical
+}
+
+// Merges user, and the status embedded in them unless it's the status
+// from which the user was reached
+func (self *EntityStore) mergeUser(user *tTwitterUser, from *tTwitterStatus) *tTwitterUser {
+	if user == nil || user.shared != nil {
+		return user
+	}
+	if user.Id == 0 {
+		// Nothing to merge with, but readers of the status still need the lock
+		user.shared = &self.lock
+		return user
+	}
+
+	// Only stored statuses are linked, latest is stored below
+	var known *tTwitterStatus
+	latest := user.Status
+	canonical, ok := self.users[user.Id]
+	switch {
+	case !ok:
+		canonical = user
+		canonical.shared = &self.lock
+		self.users[user.Id] = user
+	default:
+		known = canonical.Status
+		mergeFields(canonical, user)
+		canonical.raw, canonical.extra = user.raw, user.extra
+	}
+	canonical.Status = known
+
+	if latest != nil && latest != from && latest.Id != 0 && latest.shared == nil {
+		latest = self.putStatus(latest, canonical)
+		if canonical.Status == nil || canonical.Status.Id < latest.Id {
+			canonical.Status = latest
+		}
+	}
+
+	return canonical
+}
+
+// Stores status under its id, linked to author if known
+func (self *EntityStore) putStatus(status *tTwitterStatus, author *tTwitterUser) *tTwitterStatus {
+	canonical, ok := self.statuses[status.Id]
+	switch {
+	case !ok:
+		canonical = status
+		canonical.shared = &self.lock
+		self.statuses[status.Id] = status
+	default:
+		known := canonical.User
+		mergeFields(canonical, status)
+		canonical.raw, canonical.extra = status.raw, status.extra
+		canonical.User = known
+	}
+	canonical.createdAtSeconds = int64(parseTwitterDate(canonical.Created_at).Second())
+
+	if author != nil {
+		canonical.User = author
+	}
+	if canonical.User != nil {
+		statuses := self.byUser[canonical.User.Id]
+		if statuses == nil {
+			statuses = make(map[int64]*tTwitterStatus)
+			self.byUser[canonical.User.Id] = statuses
+		}
+		statuses[status.Id] = canonical
+	}
+
+	return canonical
+}
+
+// Copies the fields decoded from JSON from src to dst, pointers to the same
+// model struct. Unlike *dst = *src it leaves the lock pointer alone, which
+// getters read without holding the lock.
+func mergeFields(dst, src interface{}) {
+	to, from := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for i := 0; i < to.NumField(); i++ {
+		if to.Type().Field(i).PkgPath == "" {
+			to.Field(i).Set(from.Field(i))
+		}
+	}
+}
+
+// Returns the stored status for status, or status itself without a store
+func (self *Api) internStatus(status *tTwitterStatus) *tTwitterStatus {
+	if self.entities == nil {
+		return status
+	}
+	return self.entities.addStatus(status)
+}
+
+// Returns the stored user for user, or user itself without a store
+func (self *Api) internUser(user *tTwitterUser) *tTwitterUser {
+	if self.entities == nil {
+		return user
+	}
+	return self.entities.addUser(user)
+}
@@ -0,0 +1,136 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEntityStoreMergesResponses(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			switch {
+			case strings.Contains(req.URL.Path, "mentions"):
+				return `[{"id":12,"text":"b","user":{"id":1,"screen_name":"jb55","followers_count":5}},` +
+					`{"id":11,"text":"a","user":{"id":1,"screen_name":"jb55","followers_count":5}},` +
+					`{"id":13,"text":"c","user":{"id":2,"screen_name":"other"}}]`
+			case strings.Contains(req.URL.Path, "users/show"):
+				return `{"id":1,"screen_name":"jb55","followers_count":6,` +
+					`"status":{"id":14,"text":"newest"}}`
+			}
+			t.Errorf("unexpected request to %s", req.URL)
+			return ""
+		},
+	})
+	store := NewEntityStore()
+	api.SetEntityStore(store)
+
+	mentions, err := api.GetMentions(context.Background(), TimelineOptions{})
+	if err != nil {
+		t.Fatalf("GetMentions: %s", err)
+	}
+	if mentions[0].GetUser() != mentions[1].GetUser() {
+		t.Errorf("statuses of the same author got different users")
+	}
+
+	user := <-api.GetUser(ByScreenName("jb55"))
+	if user != mentions[0].GetUser() || user != <-api.GetUser(ByScreenName("jb55")) ||
+		user != store.User(1) {
+		t.Errorf("GetUser returned a new object")
+	}
+	if user.GetFollowersCount() != 6 || user.GetStatus().GetText() != "newest" {
+		t.Errorf("got %d followers, status %q", user.GetFollowersCount(), user.GetStatus().GetText())
+	}
+
+	statuses := store.StatusesByUser(1)
+	if len(statuses) != 3 || statuses[0].GetId() != 14 || statuses[2].GetId() != 11 {
+		t.Errorf("StatusesByUser: got %d statuses", len(statuses))
+	}
+	if statuses[0].GetUser() != user {
+		t.Errorf("embedded status not linked to its author")
+	}
+	if users, all := store.Len(); users != 2 || all != 4 {
+		t.Errorf("Len: got %d users %d statuses", users, all)
+	}
+}
+
+func TestEntityStoreMergesStatusesAndListOwners(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			if strings.Contains(req.URL.Path, "lists") {
+				return `{"lists":[{"id":7,"user":{"id":1,"screen_name":"jb55","name":"Bill"}}]}`
+			}
+			return `{"id":12,"text":"b","favorited":true,"user":{"id":1,"screen_name":"jb55"}}`
+		},
+	})
+	store := NewEntityStore()
+	api.SetEntityStore(store)
+	ctx := context.Background()
+
+	status, _ := api.ShowStatus(ctx, 12)
+	again, _ := api.ShowStatus(ctx, 12)
+	if status != again || !again.GetFavorited() {
+		t.Errorf("ShowStatus returned a new object")
+	}
+	if status.GetUser().GetStatus() != status {
+		t.Errorf("author not linked to their status")
+	}
+
+	lists, _, err := api.GetListOwnerships(ctx, Me(), 0)
+	if err != nil {
+		t.Fatalf("GetListOwnerships: %s", err)
+	}
+	if lists[0].GetUser() != status.GetUser() || status.GetUser().GetName() != "Bill" {
+		t.Errorf("list owner not merged with the author")
+	}
+}
+
+func TestEntityStoreReadsDuringMerges(t *testing.T) {
+	store := NewEntityStore()
+	user := store.addUser(&tTwitterUser{Id: 1, Followers_count: 1,
+		Status: &tTwitterStatus{Id: 10, Text: "a"}})
+
+	done := make(chan bool)
+	go func() {
+		for i := 2; i < 50; i++ {
+			store.addStatus(&tTwitterStatus{Id: int64(10 + i), User: &tTwitterUser{Id: 1, Followers_count: i}})
+		}
+		done <- true
+	}()
+	for i := 0; i < 50; i++ {
+		user.GetFollowersCount()
+		user.GetId()
+		user.GetStatus().GetUser().GetScreenName()
+		user.GetStatus().GetCreatedAtInSeconds()
+		json.Marshal(user)
+	}
+	<-done
+
+	if store.User(1) != user || user.GetFollowersCount() != 49 || user.GetStatus().GetId() != 59 ||
+		user.GetStatus().GetUser() != user {
+		t.Errorf("got %d followers, status %d", user.GetFollowersCount(), user.GetStatus().GetId())
+	}
+	plain := &tTwitterStatus{Id: 3, User: &tTwitterUser{Id: 1}}
+	if plain.GetUser().GetStatus() != plain {
+		t.Errorf("GetUser no longer links the user back outside the store")
+	}
+}
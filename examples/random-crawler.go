@@ -22,7 +22,7 @@ func main() {
 
 func crawl(userName string, level int) {
   // Get the user's status
-  text := (<-api.GetUser(userName)).GetStatus().GetText()
+  text := (<-api.GetUser(twitter.ByScreenName(userName))).GetStatus().GetText()
 
   for i := 0; i < level; i++ {
     fmt.Printf("  ")
@@ -37,7 +37,7 @@ func crawl(userName string, level int) {
   }
 
   // Get the user's friends
-  friends := <-api.GetFriends(userName, 1)
+  friends := <-api.GetFriends(twitter.ByScreenName(userName), 1)
   length := len(friends)
 
   if length == 0 {
@@ -0,0 +1,87 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+// The most statuses favorites/list returns per page
+const kFavoritesPage = 200
+
+// Returns the statuses a user liked, most recently liked first. Me() lists
+// the likes of the authenticated user.
+func (self *Api) GetFavorites(ctx context.Context, user UserRef,
+	opts TimelineOptions) ([]Status, error) {
+	params := url.Values{}
+	if err := user.addParams(params, ""); err != nil {
+		return nil, err
+	}
+	return self.getTimeline(ctx, _QUERY_FAVORITES, params, opts)
+}
+
+// Walks all likes of a user a page at a time, see NewFavoritesIterator
+type FavoritesIterator struct {
+	api   *Api
+	user  UserRef
+	maxId int64
+	done  bool
+}
+
+// Returns an iterator over the likes of user, for exporting them.
+//
+// Twitter pages favorites by the id of the liked status rather than by
+// when it was liked, so a page ends below the oldest status on it. Likes
+// of statuses older than that which were given later than the rest of the
+// page are skipped. Twitter also stops answering a few thousand likes deep.
+func (self *Api) NewFavoritesIterator(user UserRef) *FavoritesIterator {
+	return &FavoritesIterator{api: self, user: user}
+}
+
+// Returns the next page of likes, or io.EOF once there are none left.
+// Failed requests may be retried by calling Next again.
+func (self *FavoritesIterator) Next(ctx context.Context) ([]Status, error) {
+	if self.done {
+		return nil, io.EOF
+	}
+
+	opts := TimelineOptions{Count: kFavoritesPage, MaxId: self.maxId}
+	page, err := self.api.GetFavorites(ctx, self.user, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// max_id is inclusive, drop the status the last page ended with
+	fresh := page[:0]
+	for _, status := range page {
+		if self.maxId == 0 || status.GetId() < self.maxId {
+			fresh = append(fresh, status)
+		}
+	}
+	if len(fresh) == 0 {
+		self.done = true
+		return nil, io.EOF
+	}
+
+	for _, status := range fresh {
+		if self.maxId == 0 || status.GetId() < self.maxId {
+			self.maxId = status.GetId()
+		}
+	}
+	return fresh, nil
+}
@@ -0,0 +1,69 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestFavoritesIterator(t *testing.T) {
+	pages := map[string]string{
+		"":   `[{"id":30},{"id":10},{"id":20}]`,
+		"10": `[{"id":10},{"id":9}]`,
+		"9":  `[{"id":5},{"id":9}]`,
+		"5":  `[{"id":5}]`,
+	}
+	var maxIds []string
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			q := req.URL.Query()
+			if q.Get("screen_name") != "jb55" || q.Get("count") != "200" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+			maxIds = append(maxIds, q.Get("max_id"))
+			return pages[q.Get("max_id")]
+		},
+	})
+
+	var ids []int64
+	it := api.NewFavoritesIterator(ByScreenName("jb55"))
+	for {
+		page, err := it.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %s", err)
+		}
+		for _, status := range page {
+			ids = append(ids, status.GetId())
+		}
+	}
+
+	if len(ids) != 5 || ids[3] != 9 || ids[4] != 5 {
+		t.Errorf("got ids %v expected [30 10 20 9 5]", ids)
+	}
+	if len(maxIds) != 4 || maxIds[1] != "10" || maxIds[3] != "5" {
+		t.Errorf("got max ids %q", maxIds)
+	}
+	if _, err := it.Next(context.Background()); err != io.EOF {
+		t.Errorf("Next after the end: got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Coalesces concurrent identical calls: while a call for a key is in
+// flight, further calls for the same key wait for it and share its result
+// instead of starting their own. The zero value is ready to use.
+type tFlightGroup struct {
+	lock  sync.Mutex
+	calls map[string]*tFlight
+}
+
+type tFlight struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	// The callers still waiting, the call is canceled when the last one
+	// gives up
+	waiters int
+	data    string
+	err     error
+}
+
+// Keeps the values of a context, such as the credentials of a request, but
+// not its deadline and cancelation
+type tDetachedContext struct{ context.Context }
+
+func (tDetachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (tDetachedContext) Done() <-chan struct{} { return nil }
+
+func (tDetachedContext) Err() error { return nil }
+
+// Returns the result of fn for key, sharing it with the calls for key made
+// while fn runs. fn gets the values of the first caller's ctx but runs
+// until every caller gave up waiting, each caller stops waiting when its
+// own ctx is done.
+func (self *tFlightGroup) do(ctx context.Context, key string,
+	fn func(ctx context.Context) (string, error)) (string, error) {
+	self.lock.Lock()
+	call, ok := self.calls[key]
+	if !ok {
+		if self.calls == nil {
+			self.calls = make(map[string]*tFlight)
+		}
+		callCtx, cancel := context.WithCancel(tDetachedContext{ctx})
+		call = &tFlight{done: make(chan struct{}), cancel: cancel}
+		self.calls[key] = call
+		go self.run(callCtx, key, call, fn)
+	}
+	call.waiters++
+	self.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.data, call.err
+	case <-ctx.Done():
+	}
+
+	self.lock.Lock()
+	call.waiters--
+	if call.waiters == 0 {
+		// Nobody wants the result anymore, later callers start over
+		call.cancel()
+		self.forget(key, call)
+	}
+	self.lock.Unlock()
+	return "", ctx.Err()
+}
+
+func (self *tFlightGroup) run(ctx context.Context, key string, call *tFlight,
+	fn func(ctx context.Context) (string, error)) {
+	call.data, call.err = fn(ctx)
+
+	// Later callers start a call of their own, so they see any change
+	// made after this one finished
+	self.lock.Lock()
+	self.forget(key, call)
+	self.lock.Unlock()
+	call.cancel()
+	close(call.done)
+}
+
+// Removes call unless another call for key took its place, self.lock must
+// be held
+func (self *tFlightGroup) forget(key string, call *tFlight) {
+	if self.calls[key] == call {
+		delete(self.calls, key)
+	}
+}
@@ -0,0 +1,143 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentGetsShareARequest(t *testing.T) {
+	const callers = 5
+	release := make(chan struct{})
+	requests := 0
+
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"id":1,"screen_name":"jb55"}`,
+		check: func(req *http.Request) {
+			requests++
+			<-release
+		},
+	})
+	api.SetCache(nil)
+
+	var wg sync.WaitGroup
+	users := make([]User, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users[i] = <-api.GetUser(ByScreenName("jb55"))
+		}(i)
+	}
+
+	// Hold the request until everybody else waits for it
+	waitForWaiters(t, api, callers)
+	close(release)
+	wg.Wait()
+
+	if requests != 1 {
+		t.Errorf("sent %d requests expected 1", requests)
+	}
+	for i, user := range users {
+		if user.GetScreenName() != "jb55" {
+			t.Errorf("caller %d got %q", i, user.GetScreenName())
+		}
+	}
+	if len(api.flights.calls) != 0 {
+		t.Errorf("%d calls left in flight", len(api.flights.calls))
+	}
+}
+
+func TestFlightWaitersCancelOnTheirOwn(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan bool, 2)
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"id":1,"text":"hi"}`,
+		check: func(req *http.Request) {
+			started <- true
+			select {
+			case <-release:
+			case <-req.Context().Done():
+			}
+		},
+	})
+
+	// The first caller gives up, the second still gets the status
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := api.ShowStatus(first, 1)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan Status)
+	go func() {
+		status, err := api.ShowStatus(context.Background(), 1)
+		if err != nil {
+			t.Errorf("second ShowStatus: %s", err)
+		}
+		second <- status
+	}()
+	waitForWaiters(t, api, 2)
+
+	cancelFirst()
+	if err := <-firstErr; err != context.Canceled {
+		t.Errorf("first ShowStatus: got %v expected context.Canceled", err)
+	}
+	close(release)
+	if status := <-second; status == nil || status.GetText() != "hi" {
+		t.Errorf("second ShowStatus: got %v", status)
+	}
+
+	// Once the only caller gives up the request is canceled and the next
+	// caller starts a new one
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release = make(chan struct{})
+	if _, err := api.ShowStatus(ctx, 2); err != context.DeadlineExceeded {
+		t.Errorf("ShowStatus: got %v expected context.DeadlineExceeded", err)
+	}
+	<-started
+	close(release)
+	if _, err := api.ShowStatus(context.Background(), 2); err != nil {
+		t.Errorf("ShowStatus after giving up: %s", err)
+	}
+}
+
+func waitForWaiters(t *testing.T, api *Api, waiters int) {
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		waiting := false
+		api.flights.lock.Lock()
+		for _, call := range api.flights.calls {
+			waiting = call.waiters == waiters
+		}
+		api.flights.lock.Unlock()
+		if waiting {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("callers never coalesced")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,272 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// How two users relate to each other, seen from the source user
+type Relationship interface {
+	GetSourceId() int64
+	GetSourceScreenName() string
+	GetTargetId() int64
+	GetTargetScreenName() string
+	GetFollowing() bool
+	GetFollowedBy() bool
+	GetFollowingRequested() bool
+	GetFollowingReceived() bool
+	GetNotificationsEnabled() bool
+	GetWantRetweets() bool
+	GetBlocking() bool
+	GetMuting() bool
+	GetCanDm() bool
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+// A user and the connections the authenticated user has to them, as
+// returned by LookupFriendships
+type Friendship interface {
+	GetId() int64
+	GetName() string
+	GetScreenName() string
+	// following, following_requested, followed_by, none, blocking or muting
+	GetConnections() []string
+	HasConnection(connection string) bool
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+type tTwitterRelationshipSide struct {
+	Id                    int64  `json:"id"`
+	Screen_name           string `json:"screen_name"`
+	Following             bool   `json:"following"`
+	Followed_by           bool   `json:"followed_by"`
+	Following_requested   bool   `json:"following_requested"`
+	Following_received    bool   `json:"following_received"`
+	Notifications_enabled bool   `json:"notifications_enabled"`
+	Want_retweets         bool   `json:"want_retweets"`
+	Blocking              bool   `json:"blocking"`
+	Muting                bool   `json:"muting"`
+	Can_dm                bool   `json:"can_dm"`
+}
+
+type tTwitterRelationship struct {
+	Source tTwitterRelationshipSide `json:"source"`
+	Target tTwitterRelationshipSide `json:"target"`
+	tJsonFields
+}
+
+type tTwitterRelationshipDummy struct {
+	Relationship tTwitterRelationship
+}
+
+type tTwitterFriendship struct {
+	Id          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Screen_name string   `json:"screen_name"`
+	Connections []string `json:"connections"`
+	tJsonFields
+}
+
+func (self *tTwitterRelationship) UnmarshalJSON(data []byte) error {
+	type plain tTwitterRelationship
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterRelationship) MarshalJSON() ([]byte, error) {
+	type plain tTwitterRelationship
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterRelationship) GetSourceId() int64 { return self.Source.Id }
+
+func (self *tTwitterRelationship) GetSourceScreenName() string {
+	return self.Source.Screen_name
+}
+
+func (self *tTwitterRelationship) GetTargetId() int64 { return self.Target.Id }
+
+func (self *tTwitterRelationship) GetTargetScreenName() string {
+	return self.Target.Screen_name
+}
+
+func (self *tTwitterRelationship) GetFollowing() bool { return self.Source.Following }
+
+func (self *tTwitterRelationship) GetFollowedBy() bool { return self.Source.Followed_by }
+
+func (self *tTwitterRelationship) GetFollowingRequested() bool {
+	return self.Source.Following_requested
+}
+
+func (self *tTwitterRelationship) GetFollowingReceived() bool {
+	return self.Source.Following_received
+}
+
+func (self *tTwitterRelationship) GetNotificationsEnabled() bool {
+	return self.Source.Notifications_enabled
+}
+
+func (self *tTwitterRelationship) GetWantRetweets() bool {
+	return self.Source.Want_retweets
+}
+
+func (self *tTwitterRelationship) GetBlocking() bool { return self.Source.Blocking }
+
+func (self *tTwitterRelationship) GetMuting() bool { return self.Source.Muting }
+
+func (self *tTwitterRelationship) GetCanDm() bool { return self.Source.Can_dm }
+
+func (self *tTwitterFriendship) UnmarshalJSON(data []byte) error {
+	type plain tTwitterFriendship
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterFriendship) MarshalJSON() ([]byte, error) {
+	type plain tTwitterFriendship
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterFriendship) GetId() int64 { return self.Id }
+
+func (self *tTwitterFriendship) GetName() string { return self.Name }
+
+func (self *tTwitterFriendship) GetScreenName() string { return self.Screen_name }
+
+func (self *tTwitterFriendship) GetConnections() []string { return self.Connections }
+
+func (self *tTwitterFriendship) HasConnection(connection string) bool {
+	for _, c := range self.Connections {
+		if c == connection {
+			return true
+		}
+	}
+	return false
+}
+
+// Follows a user and returns them. Protected users get a follow request
+// instead, see OutgoingFriendshipRequests.
+func (self *Api) Follow(ctx context.Context, user UserRef) (User, error) {
+	return self.userAction(ctx, _QUERY_FOLLOW, user, url.Values{})
+}
+
+// Unfollows a user and returns them
+func (self *Api) Unfollow(ctx context.Context, user UserRef) (User, error) {
+	return self.userAction(ctx, _QUERY_UNFOLLOW, user, url.Values{})
+}
+
+// POSTs params and the user to one of the endpoints acting on a user,
+// which all answer with the affected user
+func (self *Api) userAction(ctx context.Context, url_ string, user UserRef,
+	params url.Values) (User, error) {
+	if err := user.addParams(params, ""); err != nil {
+		return nil, err
+	}
+
+	u := newEmptyTwitterUser()
+	if err := self.callJson(ctx, "POST", url_, params, u); err != nil {
+		return nil, err
+	}
+
+	return self.internUser(u), nil
+}
+
+// Returns the relationship between two users. A source of Me() compares
+// the target with the authenticated user.
+func (self *Api) ShowFriendship(ctx context.Context, source, target UserRef) (Relationship, error) {
+	var relationship tTwitterRelationshipDummy
+	params := url.Values{}
+
+	if err := source.addParams(params, "source_"); err != nil {
+		return nil, err
+	}
+	if err := target.addParams(params, "target_"); err != nil {
+		return nil, err
+	}
+
+	err := self.callJson(ctx, "GET", _QUERY_SHOWFRIENDSHIP, params, &relationship)
+	if err != nil {
+		return nil, err
+	}
+
+	return &relationship.Relationship, nil
+}
+
+// Returns the connections of the authenticated user to up to 100 users
+func (self *Api) LookupFriendships(ctx context.Context, users ...UserRef) ([]Friendship, error) {
+	var lookup []tTwitterFriendship
+
+	users, err := self.resolveMe(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+	params := lookupParams(users)
+
+	if err := self.callJson(ctx, "GET", _QUERY_LOOKUPFRIENDSHIPS, params, &lookup); err != nil {
+		return nil, err
+	}
+
+	friendships := make([]Friendship, len(lookup))
+	for i := range lookup {
+		friendships[i] = &lookup[i]
+	}
+	return friendships, nil
+}
+
+// Returns the ids of the users who asked to follow the protected,
+// authenticated user
+func (self *Api) IncomingFriendshipRequests(ctx context.Context) ([]int64, error) {
+	return self.getIds(ctx, _QUERY_INCOMINGFRIENDSHIPS, url.Values{})
+}
+
+// Returns the ids of the protected users the authenticated user asked
+// to follow
+func (self *Api) OutgoingFriendshipRequests(ctx context.Context) ([]int64, error) {
+	return self.getIds(ctx, _QUERY_OUTGOINGFRIENDSHIPS, url.Values{})
+}
+
+// Turns device notifications for the statuses of a followed user on or off
+func (self *Api) SetFriendshipNotifications(ctx context.Context, user UserRef,
+	enabled bool) (Relationship, error) {
+	return self.updateFriendship(ctx, user, "device", enabled)
+}
+
+// Turns the retweets of a followed user in the home timeline on or off
+func (self *Api) SetFriendshipRetweets(ctx context.Context, user UserRef,
+	enabled bool) (Relationship, error) {
+	return self.updateFriendship(ctx, user, "retweets", enabled)
+}
+
+func (self *Api) updateFriendship(ctx context.Context, user UserRef, key string,
+	enabled bool) (Relationship, error) {
+	var relationship tTwitterRelationshipDummy
+	params := url.Values{}
+
+	if err := user.addParams(params, ""); err != nil {
+		return nil, err
+	}
+	params.Set(key, strconv.FormatBool(enabled))
+
+	err := self.callJson(ctx, "POST", _QUERY_UPDATEFRIENDSHIP, params, &relationship)
+	if err != nil {
+		return nil, err
+	}
+
+	return &relationship.Relationship, nil
+}
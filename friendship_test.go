@@ -0,0 +1,153 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShowFriendship(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body: `{"relationship":{"source":{"id":1,"screen_name":"jb55",` +
+			`"following":true,"want_retweets":true},"target":{"id":2,` +
+			`"screen_name":"golang","followed_by":true}}}`,
+		check: func(req *http.Request) {
+			query := req.URL.Query()
+			if query.Get("source_screen_name") != "jb55" || query.Get("target_id") != "2" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+		},
+	})
+
+	relationship, err := api.ShowFriendship(context.Background(), ByScreenName("jb55"), ByID(2))
+	if err != nil {
+		t.Fatalf("ShowFriendship: %s", err)
+	}
+	if !relationship.GetFollowing() || relationship.GetFollowedBy() {
+		t.Errorf("got following %v followed by %v expected true false",
+			relationship.GetFollowing(), relationship.GetFollowedBy())
+	}
+	if relationship.GetTargetScreenName() != "golang" {
+		t.Errorf("GetTargetScreenName: got %q", relationship.GetTargetScreenName())
+	}
+}
+
+func TestLookupFriendships(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body: `[{"id":2,"screen_name":"golang","connections":["following","followed_by"]},` +
+			`{"id":3,"screen_name":"rob","connections":["none"]}]`,
+		check: func(req *http.Request) {
+			query := req.URL.Query()
+			if query.Get("user_id") != "2,3" || query.Get("screen_name") != "rob" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+		},
+	})
+
+	friendships, err := api.LookupFriendships(context.Background(), ByID(2), ByID(3), ByScreenName("rob"))
+	if err != nil {
+		t.Fatalf("LookupFriendships: %s", err)
+	}
+	if len(friendships) != 2 || !friendships[0].HasConnection("followed_by") ||
+		friendships[1].HasConnection("following") {
+		t.Errorf("unexpected friendships %v", friendships)
+	}
+}
+
+func TestLookupUsersSplitsIntoChunks(t *testing.T) {
+	var requests, inFlight, maxInFlight int32
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			if strings.HasSuffix(req.URL.Path, "verify_credentials.json") {
+				return `{"id":9918032,"screen_name":"jb55"}`
+			}
+
+			atomic.AddInt32(&requests, 1)
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for max := atomic.LoadInt32(&maxInFlight); n > max; max = atomic.LoadInt32(&maxInFlight) {
+				if atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			req.ParseForm()
+			var users []string
+			for _, id := range strings.Split(req.PostForm.Get("user_id"), ",") {
+				users = append(users, `{"id":`+id+`}`)
+			}
+			return "[" + strings.Join(users, ",") + "]"
+		},
+	})
+
+	refs := []UserRef{Me()}
+	for id := int64(1); id <= 1000; id++ {
+		refs = append(refs, ByID(id))
+	}
+
+	users, err := api.LookupUsers(context.Background(), refs)
+	if err != nil {
+		t.Fatalf("LookupUsers: %s", err)
+	}
+	if requests != 11 {
+		t.Errorf("got %d requests expected 11", requests)
+	}
+	if maxInFlight > kLookupConcurrency {
+		t.Errorf("got %d concurrent requests expected at most %d", maxInFlight, kLookupConcurrency)
+	}
+	if len(users) != 1001 || users[0].GetId() != 9918032 || users[1000].GetId() != 1000 {
+		t.Errorf("got %d users, expected Me and 1000 more in order", len(users))
+	}
+}
+
+func TestUserRefToNobodyFails(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{status: 200, check: func(req *http.Request) {
+		t.Errorf("unexpected request to %s", req.URL)
+	}})
+	ctx := context.Background()
+
+	for _, ref := range []UserRef{ByID(0), ByScreenName(""), {}} {
+		if ref.IsMe() {
+			t.Errorf("%s: IsMe", ref)
+		}
+		if _, err := api.Follow(ctx, ref); err != ErrNoUser {
+			t.Errorf("Follow(%s): got %v expected ErrNoUser", ref, err)
+		}
+		if _, err := api.FollowerIDs(ctx, ref); err != ErrNoUser {
+			t.Errorf("FollowerIDs(%s): got %v expected ErrNoUser", ref, err)
+		}
+		if _, err := api.LookupUsers(ctx, []UserRef{ByID(1), ref}); err != ErrNoUser {
+			t.Errorf("LookupUsers(%s): got %v expected ErrNoUser", ref, err)
+		}
+		if _, err := api.ShowFriendship(ctx, Me(), ref); err != ErrNoUser {
+			t.Errorf("ShowFriendship(%s): got %v expected ErrNoUser", ref, err)
+		}
+
+		<-api.GetUser(ref)
+		if err := <-api.GetErrorChannel(); !strings.Contains(err.Error(), ErrNoUser.Error()) {
+			t.Errorf("GetUser(%s): reported %v", ref, err)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// A point on earth. Twitter encodes it as a GeoJSON Point, which lists
+// the longitude first.
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// The area a place covers, a GeoJSON Polygon of [longitude, latitude]
+// pairs. The first ring is the outline, any further rings are holes.
+type BoundingBox struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// A named location such as a city or a point of interest, attached to
+// statuses or returned by the geo calls
+type Place interface {
+	GetId() string
+	GetName() string
+	GetFullName() string
+	GetCountry() string
+	GetCountryCode() string
+	// poi, neighborhood, city, admin or country
+	GetPlaceType() string
+	GetUrl() string
+	GetBoundingBox() *BoundingBox
+	// The larger places this place lies in
+	GetContainedWithin() []Place
+	// Whether the point lies in the place's bounding box
+	Contains(point Coordinates) bool
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+// Options of ReverseGeocode and GeoSearch. Zero values are left out.
+type GeoQuery struct {
+	// Free form text to match places against, GeoSearch only
+	Query string
+
+	// An IP address to locate, GeoSearch only
+	Ip string
+
+	// Only return places inside the place with this id, GeoSearch only
+	ContainedWithin string
+
+	// How far from the point places may be, such as "5ft" or "1000m"
+	Accuracy string
+
+	// The smallest kind of place to return: poi, neighborhood, city,
+	// admin or country
+	Granularity string
+
+	// The number of places to return
+	MaxResults int
+}
+
+type tTwitterPlace struct {
+	Id               string          `json:"id"`
+	Name             string          `json:"name"`
+	Full_name        string          `json:"full_name"`
+	Country          string          `json:"country"`
+	Country_code     string          `json:"country_code"`
+	Place_type       string          `json:"place_type"`
+	Url              string          `json:"url"`
+	Bounding_box     *BoundingBox    `json:"bounding_box,omitempty"`
+	Contained_within []tTwitterPlace `json:"contained_within,omitempty"`
+	tJsonFields
+}
+
+// geo/search and geo/reverse_geocode wrap their places
+type tTwitterPlaceResultDummy struct {
+	Result struct {
+		Places []tTwitterPlace
+	}
+}
+
+// The older search API sends geo as a Point with the latitude first
+type tLatLongPoint Coordinates
+
+type tGeoJsonPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+var errNotAPoint = errors.New(kErr + "not a GeoJSON Point")
+
+func (self Coordinates) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tGeoJsonPoint{"Point", [2]float64{self.Longitude, self.Latitude}})
+}
+
+func (self *Coordinates) UnmarshalJSON(data []byte) error {
+	var point tGeoJsonPoint
+	if err := json.Unmarshal(data, &point); err != nil {
+		return err
+	}
+	if point.Type != "Point" {
+		return errNotAPoint
+	}
+	self.Longitude, self.Latitude = point.Coordinates[0], point.Coordinates[1]
+	return nil
+}
+
+func (self tLatLongPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tGeoJsonPoint{"Point", [2]float64{self.Latitude, self.Longitude}})
+}
+
+func (self *tLatLongPoint) UnmarshalJSON(data []byte) error {
+	var point Coordinates
+	if err := point.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	self.Latitude, self.Longitude = point.Longitude, point.Latitude
+	return nil
+}
+
+// Returns the middle of the outline's extent
+func (self *BoundingBox) Center() Coordinates {
+	if len(self.Coordinates) == 0 || len(self.Coordinates[0]) == 0 {
+		return Coordinates{}
+	}
+
+	outline := self.Coordinates[0]
+	west, south := outline[0][0], outline[0][1]
+	east, north := west, south
+	for _, point := range outline[1:] {
+		west, east = math.Min(west, point[0]), math.Max(east, point[0])
+		south, north = math.Min(south, point[1]), math.Max(north, point[1])
+	}
+	return Coordinates{Latitude: (south + north) / 2, Longitude: (west + east) / 2}
+}
+
+// Reports whether point lies inside the outline and outside every hole.
+// Points on an edge may fall on either side.
+func (self *BoundingBox) Contains(point Coordinates) bool {
+	if len(self.Coordinates) == 0 || !ringContains(self.Coordinates[0], point) {
+		return false
+	}
+
+	for _, hole := range self.Coordinates[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ray casting: counts the edges a ray going east from point crosses. The
+// ring may or may not repeat its first point at the end.
+func ringContains(ring [][2]float64, point Coordinates) bool {
+	x, y := point.Longitude, point.Latitude
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func (self *tTwitterPlace) UnmarshalJSON(data []byte) error {
+	type plain tTwitterPlace
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterPlace) MarshalJSON() ([]byte, error) {
+	type plain tTwitterPlace
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterPlace) GetId() string { return self.Id }
+
+func (self *tTwitterPlace) GetName() string { return self.Name }
+
+func (self *tTwitterPlace) GetFullName() string { return self.Full_name }
+
+func (self *tTwitterPlace) GetCountry() string { return self.Country }
+
+func (self *tTwitterPlace) GetCountryCode() string { return self.Country_code }
+
+func (self *tTwitterPlace) GetPlaceType() string { return self.Place_type }
+
+func (self *tTwitterPlace) GetUrl() string { return self.Url }
+
+func (self *tTwitterPlace) GetBoundingBox() *BoundingBox { return self.Bounding_box }
+
+func (self *tTwitterPlace) GetContainedWithin() []Place {
+	places := make([]Place, len(self.Contained_within))
+	for i := range self.Contained_within {
+		places[i] = &self.Contained_within[i]
+	}
+	return places
+}
+
+func (self *tTwitterPlace) Contains(point Coordinates) bool {
+	return self.Bounding_box != nil && self.Bounding_box.Contains(point)
+}
+
+// Adds the options to params
+func (self *GeoQuery) addParams(params url.Values) {
+	if self.Query != "" {
+		params.Set("query", self.Query)
+	}
+	if self.Ip != "" {
+		params.Set("ip", self.Ip)
+	}
+	if self.ContainedWithin != "" {
+		params.Set("contained_within", self.ContainedWithin)
+	}
+	if self.Accuracy != "" {
+		params.Set("accuracy", self.Accuracy)
+	}
+	if self.Granularity != "" {
+		params.Set("granularity", self.Granularity)
+	}
+	if self.MaxResults > 0 {
+		params.Set("max_results", strconv.Itoa(self.MaxResults))
+	}
+}
+
+func (self Coordinates) addParams(params url.Values) {
+	params.Set("lat", strconv.FormatFloat(self.Latitude, 'f', -1, 64))
+	params.Set("long", strconv.FormatFloat(self.Longitude, 'f', -1, 64))
+}
+
+// Returns the places near a point, smallest first
+func (self *Api) ReverseGeocode(ctx context.Context, point Coordinates,
+	query GeoQuery) ([]Place, error) {
+	params := url.Values{}
+	point.addParams(params)
+	return self.getPlaces(ctx, _QUERY_REVERSEGEOCODE, params, query)
+}
+
+// Searches places by name, IP address or point. Pass a nil point to
+// search by query.Query or query.Ip alone.
+func (self *Api) GeoSearch(ctx context.Context, point *Coordinates,
+	query GeoQuery) ([]Place, error) {
+	params := url.Values{}
+	if point != nil {
+		point.addParams(params)
+	}
+	return self.getPlaces(ctx, _QUERY_GEOSEARCH, params, query)
+}
+
+// Returns a place by its id
+func (self *Api) PlaceByID(ctx context.Context, id string) (Place, error) {
+	place := new(tTwitterPlace)
+	url_ := _QUERY_PLACE + url.PathEscape(id) + ".json"
+
+	if err := self.callJson(ctx, "GET", url_, url.Values{}, place); err != nil {
+		return nil, err
+	}
+	return place, nil
+}
+
+func (self *Api) getPlaces(ctx context.Context, url_ string, params url.Values,
+	query GeoQuery) ([]Place, error) {
+	var result tTwitterPlaceResultDummy
+
+	query.addParams(params)
+	if err := self.callJson(ctx, "GET", url_, params, &result); err != nil {
+		return nil, err
+	}
+
+	places := make([]Place, len(result.Result.Places))
+	for i := range result.Result.Places {
+		places[i] = &result.Result.Places[i]
+	}
+	return places, nil
+}
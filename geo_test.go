@@ -0,0 +1,119 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const kPlaceJson = `{"id":"5a110d312052166f","name":"San Francisco",` +
+	`"full_name":"San Francisco, CA","country":"United States","country_code":"US",` +
+	`"place_type":"city","url":"https://api.twitter.com/1.1/geo/id/5a110d312052166f.json",` +
+	`"bounding_box":{"type":"Polygon","coordinates":[[[-122.514926,37.708075],` +
+	`[-122.357031,37.708075],[-122.357031,37.833238],[-122.514926,37.833238]]]}}`
+
+func TestStatusGeo(t *testing.T) {
+	in := `{"id":1,"text":"t","coordinates":{"type":"Point",` +
+		`"coordinates":[-122.4194,37.7749]},"place":` + kPlaceJson + `}`
+	status, err := ParseStatus([]byte(in))
+	if err != nil {
+		t.Fatalf("ParseStatus: %s", err)
+	}
+
+	point := status.GetCoordinates()
+	if point == nil || point.Latitude != 37.7749 || point.Longitude != -122.4194 {
+		t.Fatalf("GetCoordinates: got %v", point)
+	}
+	place := status.GetPlace()
+	if place == nil || place.GetFullName() != "San Francisco, CA" {
+		t.Fatalf("GetPlace: got %v", place)
+	}
+	if !place.Contains(*point) {
+		t.Errorf("Contains: %v not in %s", point, place.GetName())
+	}
+	if place.Contains(Coordinates{Latitude: 40.7128, Longitude: -74.0060}) {
+		t.Errorf("Contains: New York in %s", place.GetName())
+	}
+
+	data, _ := json.Marshal(status)
+	again, _ := ParseStatus(data)
+	if *again.GetCoordinates() != *point {
+		t.Errorf("round tripped coordinates differ: %s", data)
+	}
+
+	if status, _ = ParseStatus([]byte(`{"id":2}`)); status.GetPlace() != nil {
+		t.Errorf("GetPlace: got a place for a status without one")
+	}
+}
+
+func TestSearchResultGeoIsLatLong(t *testing.T) {
+	in := `{"id":1,"geo":{"type":"Point","coordinates":[37.7749,-122.4194]},` +
+		`"iso_language_code":"en"}`
+	result, err := ParseSearchResult([]byte(in))
+	if err != nil {
+		t.Fatalf("ParseSearchResult: %s", err)
+	}
+
+	if geo := result.GetGeo(); geo == nil || geo.Latitude != 37.7749 {
+		t.Errorf("GetGeo: got %v", geo)
+	}
+	if result.GetIsoLanguageCode() != "en" {
+		t.Errorf("GetIsoLanguageCode: got %q expected en", result.GetIsoLanguageCode())
+	}
+}
+
+func TestBoundingBoxHoles(t *testing.T) {
+	box := BoundingBox{Type: "Polygon", Coordinates: [][][2]float64{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{4, 4}, {6, 4}, {6, 6}, {4, 6}},
+	}}
+
+	if !box.Contains(Coordinates{Latitude: 2, Longitude: 2}) {
+		t.Errorf("Contains: (2, 2) not inside")
+	}
+	if box.Contains(Coordinates{Latitude: 5, Longitude: 5}) {
+		t.Errorf("Contains: (5, 5) inside a hole")
+	}
+	if center := box.Center(); center.Latitude != 5 || center.Longitude != 5 {
+		t.Errorf("Center: got %v", center)
+	}
+}
+
+func TestReverseGeocode(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"result":{"places":[` + kPlaceJson + `]},"query":{}}`,
+		check: func(req *http.Request) {
+			q := req.URL.Query()
+			if q.Get("lat") != "37.7749" || q.Get("long") != "-122.4194" ||
+				q.Get("granularity") != "city" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+		},
+	})
+
+	places, err := api.ReverseGeocode(context.Background(),
+		Coordinates{Latitude: 37.7749, Longitude: -122.4194}, GeoQuery{Granularity: "city"})
+	if err != nil {
+		t.Fatalf("ReverseGeocode: %s", err)
+	}
+	if len(places) != 1 || places[0].GetId() != "5a110d312052166f" {
+		t.Errorf("got %v", places)
+	}
+}
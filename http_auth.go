@@ -21,145 +21,162 @@ package twitter
 
 import (
 	"net/http"
-	"encoding/base64"
+	"encoding/json"
+	"context"
 	"io"
+	"io/ioutil"
 	"strings"
-	"net"
-	"bufio"
 	"fmt"
 	"bytes"
 	"net/url"
 )
 
-type readClose struct {
-	io.Reader
-	io.Closer
-}
+// Sends req with the Api's http client, signing it with the credentials
+// given to SetCredentials and adding the X-Twitter headers. Use it to
+// reach endpoints this package has no method for.
+//
+// The credentials go out as HTTP Basic auth, which the api.twitter.com/1.1
+// endpoints reject. Those, called by PostUpdate, PostStatus and most newer
+// methods, only work through an http.Client whose Transport signs requests
+// with OAuth 1.0a and so replaces the Authorization header, see
+// SetHTTPClient.
+func (self *Api) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", self.userAgent)
+	req.Header.Set("X-Twitter-Client", self.client)
+	req.Header.Set("X-Twitter-Client-URL", self.clientURL)
+	req.Header.Set("X-Twitter-Version", self.clientVersion)
+	if user, pass := self.requestCredentials(req.Context()); user != "" && pass != "" {
+		req.SetBasicAuth(user, pass)
+	}
 
-type badStringError struct {
-	what string
-	str  string
+	return self.httpClient.Do(req)
 }
 
-func (e *badStringError) Error() string { return fmt.Sprintf("%s %q", e.what, e.str) }
-
-// Given a string of the form "host", "host:port", or "[ipv6::address]:port",
-// return true if the string includes a port.
-func hasPort(s string) bool { return strings.LastIndex(s, ":") > strings.LastIndex(s, "]") }
-
-func send(req *http.Request) (resp *http.Response, err error) {
-	addr := req.URL.Host
-	if !hasPort(addr) {
-		addr += ":http"
-	}
-	conn, err := net.Dial("tcp", addr)
-	if err != nil {
-		return nil, err
+// Calls a REST method and decodes the JSON response into v, which may be
+// nil when the response isn't needed. GET and DELETE send params in the
+// query string, everything else sends them as a form encoded body.
+func (self *Api) callJson(ctx context.Context, method, url_ string,
+	params url.Values, v interface{}) error {
+	var body io.Reader
+
+	if method == "GET" || method == "DELETE" {
+		if len(params) > 0 {
+			url_ += "?" + params.Encode()
+		}
+	} else {
+		body = strings.NewReader(params.Encode())
 	}
 
-	err = req.Write(conn)
+	req, err := http.NewRequestWithContext(ctx, method, url_, body)
 	if err != nil {
-		conn.Close()
-		return nil, err
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
-	reader := bufio.NewReader(conn)
-	resp, err = http.ReadResponse(reader, req)
+	return self.decodeResponse(req, v)
+}
+
+// Like callJson, but POSTs body encoded as JSON, as the newer endpoints
+// expect
+func (self *Api) postJson(ctx context.Context, url_ string, body, v interface{}) error {
+	data, err := json.Marshal(body)
 	if err != nil {
-		conn.Close()
-		return nil, err
+		return err
 	}
 
-	r := io.Reader(reader)
-	if n := resp.ContentLength; n != -1 {
-		r = io.LimitReader(r, n)
+	req, err := http.NewRequestWithContext(ctx, "POST", url_, bytes.NewReader(data))
+	if err != nil {
+		return err
 	}
-	resp.Body = readClose{r, conn}
+	req.Header.Set("Content-Type", "application/json")
 
-	return
+	return self.decodeResponse(req, v)
 }
 
-func encodedUsernameAndPassword(user, pwd string) string {
-	bb := &bytes.Buffer{}
-	encoder := base64.NewEncoder(base64.StdEncoding, bb)
-	encoder.Write([]byte(user + ":" + pwd))
-	encoder.Close()
-	return bb.String()
+// Sends req and decodes the JSON response into v unless v is nil
+func (self *Api) decodeResponse(req *http.Request, v interface{}) error {
+	data, err := self.fetch(req)
+	if err != nil {
+		return err
+	}
+
+	if v == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
 }
 
-func authGet(url_, user, pwd string) (r *http.Response, err error) {
-	var req http.Request
-	h := make(http.Header)
-	h.Add("Authorization", "Basic "+
-		encodedUsernameAndPassword(user, pwd))
-	req.Header = h
-	if req.URL, err = url.Parse(url_); err != nil {
-		return
+// Sends req and returns the response body, or a *TwitterError for error
+// responses. GET requests go through the cache, other requests drop the
+// cached responses they may change.
+func (self *Api) fetch(req *http.Request) ([]byte, error) {
+	if req.Method == "GET" {
+		if cache := self.getCache(); cache != nil {
+			return self.cachedGet(cache, req)
+		}
+		return self.send(req)
 	}
-	if r, err = send(&req); err != nil {
-		return
+
+	data, err := self.send(req)
+	if err == nil {
+		self.invalidate(req.URL)
 	}
-	return
+	return data, err
 }
 
-// Post issues a POST to the specified URL.
-//
-// Caller should close r.Body when done reading it.
-func authPost(url_, user, pwd, client, clientURL, version, agent, bodyType string,
-	body io.Reader) (r *http.Response, err error) {
-	var req http.Request
-	req.Method = "POST"
-	req.Body = body.(io.ReadCloser)
-
-	h := make(http.Header)
-	h.Add("Content-Type", bodyType)
-	h.Add("Transfer-Encoding", "chunked")
-	h.Add("User-Agent", agent)
-	h.Add("X-Twitter-Client", client)
-	h.Add("X-Twitter-Client-URL", clientURL)
-	h.Add("X-Twitter-Version", version)
-	h.Add("Authorization", "Basic "+encodedUsernameAndPassword(user, pwd))
-	req.Header = h
-
-	req.URL, err = url.Parse(url_)
+// Sends req and returns the response body, or a *TwitterError for error
+// responses
+func (self *Api) send(req *http.Request) ([]byte, error) {
+	r, err := self.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	if err = CheckResponse(r); err != nil {
+		return nil, err
+	}
 
-	return send(&req)
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	return data, err
 }
 
-// Do an authenticated Get if we've called Authenticated, otherwise
-// just Get it without authentication
-func httpGet(url_, user, pass string) (*http.Response, error) {
-	var r *http.Response
-	var err error
-
-	if user != "" && pass != "" {
-		r, err = authGet(url_, user, pass)
-	} else {
-		r, err = http.Get(url_)
+// Returns a *TwitterError describing r and closes its body if r is an error
+// response, otherwise returns nil. Meant for responses of Do.
+func CheckResponse(r *http.Response) error {
+	if r.StatusCode < 400 {
+		return nil
 	}
 
-	return r, err
+	data, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	return parseErrorResponse(r.StatusCode, data)
 }
 
-// Do an authenticated Post if we've called Authenticated, otherwise
-// just Post it without authentication
-func httpPost(url_, user, pass, client, clientURL, version, agent,
-	data string) (*http.Response, error) {
-	var r *http.Response
-	var err error
-
-	body := bytes.NewBufferString(data)
-	bodyType := "application/x-www-form-urlencoded"
+// Builds a TwitterError out of an error response. Twitter sends either
+// {"errors":[{"code":..,"message":..}]} or the older {"error":".."}.
+func parseErrorResponse(statusCode int, data []byte) error {
+	var body struct {
+		Errors []struct {
+			Code    int
+			Message string
+		}
+		Error string
+	}
 
-	if user != "" && pass != "" {
-		r, err = authPost(url_, user, pass, client, clientURL,
-			version, agent, bodyType, body)
-	} else {
-		r, err = http.Post(url_, bodyType, body)
+	err := &TwitterError{statusCode: statusCode}
+	json.Unmarshal(data, &body)
+
+	switch {
+	case len(body.Errors) > 0:
+		err.code = body.Errors[0].Code
+		err.error = kErr + body.Errors[0].Message
+	case body.Error != "":
+		err.error = kErr + body.Error
+	default:
+		err.error = fmt.Sprintf("%sHTTP %d", kErr, statusCode)
 	}
 
-	return r, err
+	return err
 }
@@ -0,0 +1,106 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import "sort"
+
+// A set of user or status ids. Together with FollowerIDs and FriendIDs it
+// answers most questions about the social graph without further requests:
+//
+//    followers := NewIDSet(followerIds...)
+//    friends := NewIDSet(friendIds...)
+//
+//    mutuals := followers.Intersect(friends)
+//    notFollowingBack := friends.Difference(followers)
+//
+//    newFollowers := followers.Difference(lastWeek)
+//    lostFollowers := lastWeek.Difference(followers)
+type IDSet map[int64]struct{}
+
+// Creates a set holding ids
+func NewIDSet(ids ...int64) IDSet {
+	set := make(IDSet, len(ids))
+	set.Add(ids...)
+	return set
+}
+
+func (self IDSet) Add(ids ...int64) {
+	for _, id := range ids {
+		self[id] = struct{}{}
+	}
+}
+
+func (self IDSet) Remove(ids ...int64) {
+	for _, id := range ids {
+		delete(self, id)
+	}
+}
+
+func (self IDSet) Has(id int64) bool {
+	_, ok := self[id]
+	return ok
+}
+
+func (self IDSet) Len() int { return len(self) }
+
+// Returns a new set with the ids in either set
+func (self IDSet) Union(other IDSet) IDSet {
+	union := make(IDSet, len(self)+len(other))
+	for id := range self {
+		union[id] = struct{}{}
+	}
+	for id := range other {
+		union[id] = struct{}{}
+	}
+	return union
+}
+
+// Returns a new set with the ids in both sets
+func (self IDSet) Intersect(other IDSet) IDSet {
+	small, large := self, other
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+
+	intersection := make(IDSet)
+	for id := range small {
+		if large.Has(id) {
+			intersection[id] = struct{}{}
+		}
+	}
+	return intersection
+}
+
+// Returns a new set with the ids of this set that aren't in other
+func (self IDSet) Difference(other IDSet) IDSet {
+	difference := make(IDSet)
+	for id := range self {
+		if !other.Has(id) {
+			difference[id] = struct{}{}
+		}
+	}
+	return difference
+}
+
+// Returns the ids in ascending order
+func (self IDSet) Slice() []int64 {
+	ids := make([]int64, 0, len(self))
+	for id := range self {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
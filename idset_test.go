@@ -0,0 +1,97 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIDSetOperations(t *testing.T) {
+	followers := NewIDSet(1, 2, 3, 4)
+	friends := NewIDSet(3, 4, 5)
+
+	tests := []struct {
+		name     string
+		set      IDSet
+		expected string
+	}{
+		{"Union", followers.Union(friends), "[1 2 3 4 5]"},
+		{"Intersect", followers.Intersect(friends), "[3 4]"},
+		{"Difference", friends.Difference(followers), "[5]"},
+		{"Difference", followers.Difference(friends), "[1 2]"},
+	}
+
+	for _, test := range tests {
+		if got := fmt.Sprint(test.set.Slice()); got != test.expected {
+			t.Errorf("%s: got %s expected %s", test.name, got, test.expected)
+		}
+	}
+
+	if followers.Len() != 4 || !followers.Has(1) || followers.Has(5) {
+		t.Errorf("operations modified their operands: %v", followers.Slice())
+	}
+}
+
+func TestFollowerIDsFollowsCursors(t *testing.T) {
+	pages := map[string]string{
+		"-1": `{"ids":[1,2],"next_cursor":7}`,
+		"7":  `{"ids":[3],"next_cursor":0}`,
+	}
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			if req.URL.Query().Get("screen_name") != "jb55" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+			return pages[req.URL.Query().Get("cursor")]
+		},
+	})
+
+	ids, err := api.FollowerIDs(context.Background(), ByScreenName("jb55"))
+	if err != nil {
+		t.Fatalf("FollowerIDs: %s", err)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("got %v expected [1 2 3]", ids)
+	}
+}
+
+func TestFollowerIDsStopsOnRepeatedCursor(t *testing.T) {
+	var requests int
+	pages := map[string]string{
+		"-1": `{"ids":[1,2],"next_cursor":7}`,
+		"7":  `{"ids":[3],"next_cursor":8}`,
+		"8":  `{"ids":[4],"next_cursor":7}`,
+	}
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		reply: func(req *http.Request) string {
+			requests++
+			return pages[req.URL.Query().Get("cursor")]
+		},
+	})
+
+	ids, err := api.FollowerIDs(context.Background(), ByScreenName("jb55"))
+	if err != nil {
+		t.Fatalf("FollowerIDs: %s", err)
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4]" || requests != 3 {
+		t.Errorf("got %v after %d requests expected [1 2 3 4] after 3", ids, requests)
+	}
+}
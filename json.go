@@ -0,0 +1,214 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Gives access to the JSON a model was decoded from, including attributes
+// the library doesn't know about yet. Every model implements it.
+type RawFields interface {
+	// The original JSON object, nil if the model wasn't decoded from JSON
+	Raw() json.RawMessage
+
+	// Looks up a member by a dotted path such as "entities.urls.0.url".
+	// Numeric path elements index into arrays.
+	Field(path string) (json.RawMessage, bool)
+
+	// Like Field, but decodes the member. The zero value is returned when
+	// the member is missing or has a different type.
+	FieldString(path string) string
+	FieldInt64(path string) int64
+	FieldBool(path string) bool
+}
+
+// Keeps the JSON object a model struct was decoded from and the members it
+// has no field for, so they survive a decode/encode round trip. Embedded in
+// every model.
+type tJsonFields struct {
+	raw   json.RawMessage
+	extra map[string]json.RawMessage
+
+	// The lock of the EntityStore holding the model, nil if none does. The
+	// store merges newer responses into the model while holding it.
+	shared *sync.RWMutex
+}
+
+// Read locks the model against merges by its EntityStore and returns the
+// unlock, both do nothing for models of no store. Getters must not call each
+// other while holding it, a nested read lock deadlocks against a waiting
+// merge.
+func (self *tJsonFields) read() func() {
+	if self.shared == nil {
+		return func() {}
+	}
+	self.shared.RLock()
+	return self.shared.RUnlock
+}
+
+var jsonKeyCache sync.Map
+
+// Decodes data into v, a pointer to a method-less copy of the model struct,
+// and remembers every member v doesn't know about
+func (self *tJsonFields) unmarshalFields(data []byte, v interface{}) error {
+	var members map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	self.raw = append(json.RawMessage(nil), data...)
+
+	known := jsonKeys(v)
+	self.extra = nil
+	for key, value := range members {
+		if known[strings.ToLower(key)] {
+			continue
+		}
+		if self.extra == nil {
+			self.extra = make(map[string]json.RawMessage)
+		}
+		self.extra[key] = value
+	}
+
+	return nil
+}
+
+// Encodes v along with the remembered unknown members. Members are always
+// written in sorted order so the output is stable.
+func (self *tJsonFields) marshalFields(v interface{}) ([]byte, error) {
+	var members map[string]json.RawMessage
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	for key, value := range self.extra {
+		if _, ok := members[key]; !ok {
+			members[key] = value
+		}
+	}
+
+	return json.Marshal(members)
+}
+
+func (self *tJsonFields) Raw() json.RawMessage {
+	defer self.read()()
+	return self.raw
+}
+
+func (self *tJsonFields) Field(path string) (json.RawMessage, bool) {
+	unlock := self.read()
+	value := self.raw
+	unlock()
+	if value == nil {
+		return nil, false
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		var members map[string]json.RawMessage
+		var elements []json.RawMessage
+		var ok bool
+
+		if json.Unmarshal(value, &members) == nil && members != nil {
+			if value, ok = members[key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+
+		index, err := strconv.Atoi(key)
+		if err != nil || json.Unmarshal(value, &elements) != nil ||
+			index < 0 || index >= len(elements) {
+			return nil, false
+		}
+		value = elements[index]
+	}
+
+	return value, true
+}
+
+func (self *tJsonFields) FieldString(path string) string {
+	var s string
+	if value, ok := self.Field(path); ok {
+		json.Unmarshal(value, &s)
+	}
+	return s
+}
+
+// Also accepts numbers encoded as strings, such as the id_str members
+func (self *tJsonFields) FieldInt64(path string) int64 {
+	var i int64
+	value, ok := self.Field(path)
+	if !ok {
+		return 0
+	}
+	if json.Unmarshal(value, &i) != nil {
+		i, _ = strconv.ParseInt(self.FieldString(path), 10, 64)
+	}
+	return i
+}
+
+func (self *tJsonFields) FieldBool(path string) bool {
+	var b bool
+	if value, ok := self.Field(path); ok {
+		json.Unmarshal(value, &b)
+	}
+	return b
+}
+
+// Returns the lower cased JSON member names of the struct v points to
+func jsonKeys(v interface{}) map[string]bool {
+	typ := reflect.TypeOf(v)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if keys, ok := jsonKeyCache.Load(typ); ok {
+		return keys.(map[string]bool)
+	}
+
+	keys := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		keys[strings.ToLower(name)] = true
+	}
+
+	jsonKeyCache.Store(typ, keys)
+	return keys
+}
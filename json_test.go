@@ -0,0 +1,138 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const kStatusJson = `{"created_at":"Tue Nov 10 21:33:50 +0000 2009",` +
+	`"favorited":false,"id":5641609144,"id_str":"5641609144",` +
+	`"in_reply_to_screen_name":"","in_reply_to_status_id":0,` +
+	`"in_reply_to_user_id":0,"text":"hello from go",` +
+	`"user":{"id":9918032,"name":"Bill Casarin","screen_name":"jb55",` +
+	`"favourites_count":3,"time_zone":"Pacific Time (US & Canada)",` +
+	`"verified":false}}`
+
+func TestStatusJsonRoundTrip(t *testing.T) {
+	status, err := ParseStatus([]byte(kStatusJson))
+	if err != nil {
+		t.Fatalf("ParseStatus: %s", err)
+	}
+
+	if status.GetId() != 5641609144 {
+		t.Errorf("GetId: got %d expected 5641609144", status.GetId())
+	}
+	if status.GetUser().GetScreenName() != "jb55" {
+		t.Errorf("GetScreenName: got %q expected jb55",
+			status.GetUser().GetScreenName())
+	}
+	if status.GetUser().GetFavoritesCount() != 3 {
+		t.Errorf("GetFavoritesCount: got %d expected 3",
+			status.GetUser().GetFavoritesCount())
+	}
+
+	// GetUser links the user back to the status, marshaling must not loop
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("json.Marshal: %s", err)
+	}
+
+	again, err := ParseStatus(data)
+	if err != nil {
+		t.Fatalf("ParseStatus(marshaled): %s", err)
+	}
+	if !StatusEqual(status, again) {
+		t.Errorf("round tripped status differs: %s", data)
+	}
+
+	second, _ := json.Marshal(again)
+	if string(data) != string(second) {
+		t.Errorf("serialization not stable:\n%s\n%s", data, second)
+	}
+
+	var members map[string]map[string]interface{}
+	json.Unmarshal([]byte(`{"s":`+string(data)+`}`), &members)
+	if members["s"]["id_str"] != "5641609144" {
+		t.Errorf("unknown member id_str was dropped: %s", data)
+	}
+}
+
+func TestUserJsonKeepsUnknownFields(t *testing.T) {
+	user, err := ParseUser([]byte(`{"id":1,"screen_name":"a","verified":true}`))
+	if err != nil {
+		t.Fatalf("ParseUser: %s", err)
+	}
+
+	data, _ := json.Marshal(user)
+	var members map[string]interface{}
+	json.Unmarshal(data, &members)
+	if members["verified"] != true {
+		t.Errorf("unknown member verified was dropped: %s", data)
+	}
+	if members["screen_name"] != "a" {
+		t.Errorf("screen_name: got %v expected a", members["screen_name"])
+	}
+}
+
+func TestSearchResultJsonRoundTrip(t *testing.T) {
+	in := `{"from_user":"jb55","id":42,"metadata":{"result_type":"recent"},"text":"hi"}`
+	result, err := ParseSearchResult([]byte(in))
+	if err != nil {
+		t.Fatalf("ParseSearchResult: %s", err)
+	}
+
+	data, _ := json.Marshal(result)
+	again, _ := ParseSearchResult(data)
+	if again.GetFromUser() != "jb55" || again.GetId() != 42 {
+		t.Errorf("round tripped result differs: %s", data)
+	}
+
+	out, _ := json.Marshal(again)
+	if string(out) != string(data) {
+		t.Errorf("serialization not stable:\n%s\n%s", data, out)
+	}
+}
+
+func TestRawFieldLookup(t *testing.T) {
+	in := `{"id":7,"text":"t","edit_history_tweet_ids":["7","8"],` +
+		`"entities":{"urls":[{"expanded_url":"http://golang.org"}]},` +
+		`"possibly_sensitive":true,"user":{"id":1,"id_str":"1"}}`
+	status, err := ParseStatus([]byte(in))
+	if err != nil {
+		t.Fatalf("ParseStatus: %s", err)
+	}
+
+	if string(status.Raw()) != in {
+		t.Errorf("Raw: got %s expected %s", status.Raw(), in)
+	}
+	if got := status.FieldInt64("edit_history_tweet_ids.1"); got != 8 {
+		t.Errorf("FieldInt64: got %d expected 8", got)
+	}
+	if got := status.FieldString("entities.urls.0.expanded_url"); got != "http://golang.org" {
+		t.Errorf("FieldString: got %q expected http://golang.org", got)
+	}
+	if !status.FieldBool("possibly_sensitive") {
+		t.Errorf("FieldBool: got false expected true")
+	}
+	if _, ok := status.Field("entities.urls.1"); ok {
+		t.Errorf("Field: out of range index found")
+	}
+	if got := status.GetUser().FieldInt64("id_str"); got != 1 {
+		t.Errorf("user FieldInt64: got %d expected 1", got)
+	}
+}
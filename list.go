@@ -0,0 +1,268 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// A curated list of users
+type List interface {
+	GetId() int64
+	GetName() string
+	GetSlug() string
+	GetFullName() string
+	GetDescription() string
+	// public or private
+	GetMode() string
+	GetUri() string
+	GetCreatedAt() string
+	GetMemberCount() int
+	GetSubscriberCount() int
+	// Whether the authenticated user subscribed to the list
+	GetFollowing() bool
+	// The owner of the list
+	GetUser() User
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+// The attributes of a list to create or update. Empty fields are left
+// out, so UpdateList only changes the fields that are set.
+type ListUpdate struct {
+	Name        string
+	Description string
+	// public or private
+	Mode string
+}
+
+type tTwitterList struct {
+	Id               int64         `json:"id"`
+	Name             string        `json:"name"`
+	Slug             string        `json:"slug"`
+	Full_name        string        `json:"full_name"`
+	Description      string        `json:"description"`
+	Mode             string        `json:"mode"`
+	Uri              string        `json:"uri"`
+	Created_at       string        `json:"created_at"`
+	Member_count     int           `json:"member_count"`
+	Subscriber_count int           `json:"subscriber_count"`
+	Following        bool          `json:"following"`
+	User             *tTwitterUser `json:"user,omitempty"`
+	tJsonFields
+}
+
+type tTwitterListPage struct {
+	Lists       []tTwitterList
+	Next_cursor int64
+}
+
+type tTwitterUserPage struct {
+	Users       []tTwitterUser
+	Next_cursor int64
+}
+
+func (self *tTwitterList) UnmarshalJSON(data []byte) error {
+	type plain tTwitterList
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterList) MarshalJSON() ([]byte, error) {
+	type plain tTwitterList
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterList) GetId() int64 { return self.Id }
+
+func (self *tTwitterList) GetName() string { return self.Name }
+
+func (self *tTwitterList) GetSlug() string { return self.Slug }
+
+func (self *tTwitterList) GetFullName() string { return self.Full_name }
+
+func (self *tTwitterList) GetDescription() string { return self.Description }
+
+func (self *tTwitterList) GetMode() string { return self.Mode }
+
+func (self *tTwitterList) GetUri() string { return self.Uri }
+
+func (self *tTwitterList) GetCreatedAt() string { return self.Created_at }
+
+func (self *tTwitterList) GetMemberCount() int { return self.Member_count }
+
+func (self *tTwitterList) GetSubscriberCount() int { return self.Subscriber_count }
+
+func (self *tTwitterList) GetFollowing() bool { return self.Following }
+
+func (self *tTwitterList) GetUser() User {
+	if self.User == nil {
+		self.User = newEmptyTwitterUser()
+	}
+	return self.User
+}
+
+func (self *ListUpdate) addParams(params url.Values) {
+	if self.Name != "" {
+		params.Set("name", self.Name)
+	}
+	if self.Description != "" {
+		params.Set("description", self.Description)
+	}
+	if self.Mode != "" {
+		params.Set("mode", self.Mode)
+	}
+}
+
+func listParams(listId int64) url.Values {
+	return url.Values{"list_id": {strconv.FormatInt(listId, 10)}}
+}
+
+// Creates a list owned by the authenticated user. Name is required, lists
+// are public unless Mode is private.
+func (self *Api) CreateList(ctx context.Context, update ListUpdate) (List, error) {
+	params := url.Values{}
+	update.addParams(params)
+	return self.listAction(ctx, _QUERY_CREATELIST, params)
+}
+
+// Changes the fields of a list that are set in update
+func (self *Api) UpdateList(ctx context.Context, listId int64, update ListUpdate) (List, error) {
+	params := listParams(listId)
+	update.addParams(params)
+	return self.listAction(ctx, _QUERY_UPDATELIST, params)
+}
+
+// Deletes a list of the authenticated user and returns it
+func (self *Api) DestroyList(ctx context.Context, listId int64) (List, error) {
+	return self.listAction(ctx, _QUERY_DESTROYLIST, listParams(listId))
+}
+
+func (self *Api) listAction(ctx context.Context, url_ string, params url.Values) (List, error) {
+	list := new(tTwitterList)
+	if err := self.callJson(ctx, "POST", url_, params, list); err != nil {
+		return nil, err
+	}
+	list.User = self.internUser(list.User)
+	return list, nil
+}
+
+// Adds users to a list, 100 users per request
+func (self *Api) AddListMembers(ctx context.Context, listId int64, users []UserRef) error {
+	return self.changeListMembers(ctx, _QUERY_ADDLISTMEMBERS, listId, users)
+}
+
+// Removes users from a list, 100 users per request
+func (self *Api) RemoveListMembers(ctx context.Context, listId int64, users []UserRef) error {
+	return self.changeListMembers(ctx, _QUERY_REMOVELISTMEMBERS, listId, users)
+}
+
+func (self *Api) changeListMembers(ctx context.Context, url_ string, listId int64,
+	users []UserRef) error {
+	users, err := self.resolveMe(ctx, users)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(users); start += kLookupChunk {
+		end := start + kLookupChunk
+		if end > len(users) {
+			end = len(users)
+		}
+
+		params := lookupParams(users[start:end])
+		params.Set("list_id", strconv.FormatInt(listId, 10))
+		if err := self.callJson(ctx, "POST", url_, params, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns a page of the members of a list
+//
+// cursor:
+//  0 for the first page, then the cursor returned with the previous page.
+//  The last page returns a cursor of 0.
+func (self *Api) GetListMembers(ctx context.Context, listId int64, cursor int64) ([]User, int64, error) {
+	var page tTwitterUserPage
+
+	params := listParams(listId)
+	params.Set("cursor", strconv.FormatInt(firstCursor(cursor), 10))
+	if err := self.callJson(ctx, "GET", _QUERY_LISTMEMBERS, params, &page); err != nil {
+		return nil, 0, err
+	}
+
+	users := make([]User, len(page.Users))
+	for i := range page.Users {
+		users[i] = self.internUser(&page.Users[i])
+	}
+	return users, page.Next_cursor, nil
+}
+
+// Returns a page of the lists a user was added to. Cursors work as for
+// GetListMembers.
+func (self *Api) GetListMemberships(ctx context.Context, user UserRef, cursor int64) ([]List, int64, error) {
+	return self.getLists(ctx, _QUERY_LISTMEMBERSHIPS, user, cursor)
+}
+
+// Returns a page of the lists a user subscribed to. Cursors work as for
+// GetListMembers.
+func (self *Api) GetListSubscriptions(ctx context.Context, user UserRef, cursor int64) ([]List, int64, error) {
+	return self.getLists(ctx, _QUERY_LISTSUBSCRIPTIONS, user, cursor)
+}
+
+// Returns a page of the lists a user owns. Cursors work as for
+// GetListMembers.
+func (self *Api) GetListOwnerships(ctx context.Context, user UserRef, cursor int64) ([]List, int64, error) {
+	return self.getLists(ctx, _QUERY_LISTOWNERSHIPS, user, cursor)
+}
+
+func (self *Api) getLists(ctx context.Context, url_ string, user UserRef,
+	cursor int64) ([]List, int64, error) {
+	var page tTwitterListPage
+	params := url.Values{}
+
+	if err := user.addParams(params, ""); err != nil {
+		return nil, 0, err
+	}
+	params.Set("cursor", strconv.FormatInt(firstCursor(cursor), 10))
+	if err := self.callJson(ctx, "GET", url_, params, &page); err != nil {
+		return nil, 0, err
+	}
+
+	lists := make([]List, len(page.Lists))
+	for i := range page.Lists {
+		page.Lists[i].User = self.internUser(page.Lists[i].User)
+		lists[i] = &page.Lists[i]
+	}
+	return lists, page.Next_cursor, nil
+}
+
+// Returns the statuses of a list's members, newest first
+func (self *Api) GetListStatuses(ctx context.Context, listId int64, opts TimelineOptions) ([]Status, error) {
+	return self.getTimeline(ctx, _QUERY_LISTSTATUSES, listParams(listId), opts)
+}
+
+// Twitter starts cursored lists at -1, we let 0 mean the same
+func firstCursor(cursor int64) int64 {
+	if cursor == 0 {
+		return -1
+	}
+	return cursor
+}
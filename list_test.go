@@ -0,0 +1,73 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAddListMembersInChunks(t *testing.T) {
+	var sizes []int
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"id":5}`,
+		check: func(req *http.Request) {
+			req.ParseForm()
+			if req.PostForm.Get("list_id") != "5" {
+				t.Errorf("got form %v", req.PostForm)
+			}
+			sizes = append(sizes, len(strings.Split(req.PostForm.Get("user_id"), ",")))
+		},
+	})
+
+	var users []UserRef
+	for id := int64(1); id <= 230; id++ {
+		users = append(users, ByID(id))
+	}
+
+	if err := api.AddListMembers(context.Background(), 5, users); err != nil {
+		t.Fatalf("AddListMembers: %s", err)
+	}
+	if len(sizes) != 3 || sizes[0] != 100 || sizes[2] != 30 {
+		t.Errorf("got chunks of %v expected [100 100 30]", sizes)
+	}
+}
+
+func TestGetListStatuses(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `[{"id":9,"text":"a","user":{"id":1}},{"id":8,"text":"b","user":{"id":2}}]`,
+		check: func(req *http.Request) {
+			query := req.URL.Query()
+			if query.Get("list_id") != "5" || query.Get("since_id") != "7" ||
+				query.Get("max_id") != "" || query.Get("count") != "2" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+		},
+	})
+
+	statuses, err := api.GetListStatuses(context.Background(), 5,
+		TimelineOptions{Count: 2, SinceId: 7})
+	if err != nil {
+		t.Fatalf("GetListStatuses: %s", err)
+	}
+	if len(statuses) != 2 || statuses[1].GetUser().GetId() != 2 {
+		t.Errorf("got %d statuses", len(statuses))
+	}
+}
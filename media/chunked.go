@@ -0,0 +1,237 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"twitter"
+)
+
+// Where a chunked upload stands. Failed uploads return it inside an
+// *UploadError so they can be continued with Resume.
+type UploadState struct {
+	MediaId   int64
+	MediaType string
+	Size      int64
+	ChunkSize int
+
+	// The number of segments the server acknowledged
+	Segments int
+
+	// Whether FINALIZE went through and only processing is left
+	Finalized bool
+}
+
+// The error of a chunked upload that gave up after MaxRetries
+type UploadError struct {
+	State *UploadState
+	Err   error
+}
+
+func (self *UploadError) Error() string {
+	return fmt.Sprintf("media upload %d failed after %d segments: %s",
+		self.State.MediaId, self.State.Segments, self.Err)
+}
+
+func (self *UploadError) Unwrap() error { return self.Err }
+
+// Uploads size bytes of r with the INIT/APPEND/FINALIZE flow and waits until
+// the server has processed them. Segments that fail with a network or
+// server error are retried, see Resume for continuing after that.
+func (self *Uploader) UploadChunked(ctx context.Context, r io.ReaderAt, size int64,
+	mediaType string) (*Media, error) {
+	media := new(Media)
+
+	err := self.postMultipart(ctx, map[string]string{
+		"command":        "INIT",
+		"total_bytes":    strconv.FormatInt(size, 10),
+		"media_type":     mediaType,
+		"media_category": category(mediaType),
+	}, nil, media)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &UploadState{
+		MediaId:   media.MediaId,
+		MediaType: mediaType,
+		Size:      size,
+		ChunkSize: self.ChunkSize,
+	}
+	if state.ChunkSize <= 0 {
+		state.ChunkSize = kDefaultChunkSize
+	}
+	return self.Resume(ctx, r, state)
+}
+
+// Continues a chunked upload from its last acknowledged segment. r must
+// hold the same data that was given to UploadChunked.
+func (self *Uploader) Resume(ctx context.Context, r io.ReaderAt, state *UploadState) (*Media, error) {
+	if state.ChunkSize <= 0 {
+		return nil, fmt.Errorf("media upload %d: invalid chunk size %d",
+			state.MediaId, state.ChunkSize)
+	}
+
+	media := &Media{MediaId: state.MediaId}
+	chunk := int64(state.ChunkSize)
+	buf := make([]byte, chunk)
+	id := strconv.FormatInt(state.MediaId, 10)
+
+	for offset := int64(state.Segments) * chunk; !state.Finalized && offset < state.Size; offset += chunk {
+		n := chunk
+		if state.Size-offset < n {
+			n = state.Size - offset
+		}
+		if read, err := r.ReadAt(buf[:n], offset); int64(read) < n {
+			return nil, &UploadError{state, err}
+		}
+
+		fields := map[string]string{
+			"command":       "APPEND",
+			"media_id":      id,
+			"segment_index": strconv.Itoa(state.Segments),
+		}
+		err := self.retry(ctx, func() error {
+			return self.postMultipart(ctx, fields, buf[:n], nil)
+		})
+		if err != nil {
+			return nil, &UploadError{state, err}
+		}
+
+		state.Segments++
+		if self.Progress != nil {
+			self.Progress(offset+n, state.Size)
+		}
+	}
+
+	if !state.Finalized {
+		err := self.retry(ctx, func() error {
+			fields := map[string]string{"command": "FINALIZE", "media_id": id}
+			return self.postMultipart(ctx, fields, nil, media)
+		})
+		if err != nil {
+			return nil, &UploadError{state, err}
+		}
+		state.Finalized = true
+	} else if err := self.status(ctx, media); err != nil {
+		return nil, &UploadError{state, err}
+	}
+
+	if err := self.waitForProcessing(ctx, media); err != nil {
+		return nil, &UploadError{state, err}
+	}
+	return media, nil
+}
+
+// Polls STATUS, waiting as long as the server asks in between, until the
+// processing of media either succeeded or failed
+func (self *Uploader) waitForProcessing(ctx context.Context, media *Media) error {
+	for media.ProcessingInfo != nil {
+		info := media.ProcessingInfo
+
+		switch info.State {
+		case "succeeded":
+			return nil
+		case "failed":
+			if info.Error != nil {
+				return fmt.Errorf("media processing failed: %s", info.Error.Message)
+			}
+			return fmt.Errorf("media processing failed")
+		}
+
+		timer := time.NewTimer(time.Duration(info.CheckAfterSecs) * time.Second)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		err := self.retry(ctx, func() error { return self.status(ctx, media) })
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Fetches the processing state of media
+func (self *Uploader) status(ctx context.Context, media *Media) error {
+	params := url.Values{}
+	params.Set("command", "STATUS")
+	params.Set("media_id", strconv.FormatInt(media.MediaId, 10))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", self.UploadUrl+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	return self.send(req, media)
+}
+
+// Calls fn until it succeeds, fails for good or MaxRetries is used up.
+// Returns ctx.Err() once ctx is done.
+func (self *Uploader) retry(ctx context.Context, fn func() error) error {
+	for try := 0; ; try++ {
+		err := fn()
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil || try >= self.MaxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		timer := time.NewTimer(self.RetryWait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Network errors and server side failures are worth another try, anything
+// the server rejected is not
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if terr, ok := err.(*twitter.TwitterError); ok {
+		return terr.GetStatusCode() >= 500
+	}
+	return true
+}
+
+// Picks the media_category for a MIME type
+func category(mediaType string) string {
+	switch {
+	case mediaType == "image/gif":
+		return "tweet_gif"
+	case strings.HasPrefix(mediaType, "video/"):
+		return "tweet_video"
+	}
+	return "tweet_image"
+}
@@ -0,0 +1,204 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Package media uploads images, GIFs and videos for attaching to statuses
+// through twitter.StatusUpdate.MediaIds.
+//
+//    uploader := media.NewUploader(api)
+//    m, err := uploader.Upload(ctx, data, "video/mp4")
+//    ...
+//    api.PostStatus(ctx, twitter.StatusUpdate{Status: "look",
+//      MediaIds: []int64{m.MediaId}})
+//
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"twitter"
+)
+
+const (
+	DefaultUploadUrl   = "https://upload.twitter.com/1.1/media/upload.json"
+	DefaultMetadataUrl = "https://upload.twitter.com/1.1/media/metadata/create.json"
+
+	// Images up to this size are sent in a single request by Upload
+	kMaxSimpleSize    = 5 * 1024 * 1024
+	kDefaultChunkSize = 1024 * 1024
+	kDefaultRetries   = 3
+	kDefaultRetryWait = time.Second
+)
+
+// Sends authenticated requests, *twitter.Api satisfies it
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// An uploaded media object
+type Media struct {
+	MediaId          int64           `json:"media_id"`
+	MediaIdString    string          `json:"media_id_string"`
+	Size             int64           `json:"size"`
+	ExpiresAfterSecs int             `json:"expires_after_secs"`
+	ProcessingInfo   *ProcessingInfo `json:"processing_info"`
+}
+
+// The server side processing state of a GIF or video
+type ProcessingInfo struct {
+	// pending, in_progress, failed or succeeded
+	State           string `json:"state"`
+	CheckAfterSecs  int    `json:"check_after_secs"`
+	ProgressPercent int    `json:"progress_percent"`
+	Error           *struct {
+		Code    int    `json:"code"`
+		Name    string `json:"name"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Uploads media. The exported fields may be changed before uploading.
+type Uploader struct {
+	api Doer
+
+	UploadUrl   string
+	MetadataUrl string
+
+	// The size of the APPEND segments of a chunked upload
+	ChunkSize int
+
+	// How often a failed segment is retried before giving up, and how
+	// long to wait between tries
+	MaxRetries int
+	RetryWait  time.Duration
+
+	// Called after every acknowledged segment of a chunked upload
+	Progress func(sent, total int64)
+}
+
+// Creates an Uploader sending its requests through api
+func NewUploader(api Doer) *Uploader {
+	return &Uploader{
+		api:         api,
+		UploadUrl:   DefaultUploadUrl,
+		MetadataUrl: DefaultMetadataUrl,
+		ChunkSize:   kDefaultChunkSize,
+		MaxRetries:  kDefaultRetries,
+		RetryWait:   kDefaultRetryWait,
+	}
+}
+
+// Uploads data of the given MIME type. Small still images are sent in a
+// single request, GIFs, videos and large images use the chunked flow and
+// wait for processing to finish.
+func (self *Uploader) Upload(ctx context.Context, data []byte, mediaType string) (*Media, error) {
+	if strings.HasPrefix(mediaType, "image/") && mediaType != "image/gif" &&
+		len(data) <= kMaxSimpleSize {
+		return self.UploadSimple(ctx, data)
+	}
+
+	return self.UploadChunked(ctx, bytes.NewReader(data), int64(len(data)), mediaType)
+}
+
+// Uploads an image in a single multipart request
+func (self *Uploader) UploadSimple(ctx context.Context, data []byte) (*Media, error) {
+	media := new(Media)
+	err := self.postMultipart(ctx, nil, data, media)
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// Sets the alternative text read by screen readers for an uploaded image
+// or GIF
+func (self *Uploader) SetAltText(ctx context.Context, mediaId int64, text string) error {
+	var body struct {
+		MediaId string `json:"media_id"`
+		AltText struct {
+			Text string `json:"text"`
+		} `json:"alt_text"`
+	}
+
+	body.MediaId = strconv.FormatInt(mediaId, 10)
+	body.AltText.Text = text
+	data, err := json.Marshal(&body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", self.MetadataUrl, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return self.send(req, nil)
+}
+
+// Posts fields and, if non nil, data as the media part of a multipart form
+func (self *Uploader) postMultipart(ctx context.Context, fields map[string]string,
+	data []byte, v interface{}) error {
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+	for key, value := range fields {
+		form.WriteField(key, value)
+	}
+	if data != nil {
+		part, err := form.CreateFormFile("media", "blob")
+		if err != nil {
+			return err
+		}
+		part.Write(data)
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", self.UploadUrl, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	return self.send(req, v)
+}
+
+// Sends req and decodes the JSON response into v if v isn't nil
+func (self *Uploader) send(req *http.Request, v interface{}) error {
+	r, err := self.api.Do(req)
+	if err != nil {
+		return err
+	}
+	if err = twitter.CheckResponse(r); err != nil {
+		return err
+	}
+
+	defer r.Body.Close()
+	if v == nil {
+		io.Copy(ioutil.Discard, r.Body)
+		return nil
+	}
+	return json.NewDecoder(r.Body).Decode(v)
+}
@@ -0,0 +1,229 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"twitter"
+)
+
+// A local stand in for upload.twitter.com
+type tFakeUploadServer struct {
+	mutex    sync.Mutex
+	segments map[int][]byte
+	commands []string
+	altText  string
+
+	// Segment whose first APPEND gets its connection dropped
+	dropSegment int
+	dropped     bool
+
+	// STATUS calls left before processing succeeds
+	pendingChecks int
+}
+
+func (self *tFakeUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if r.URL.Path == "/metadata" {
+		body, _ := ioutil.ReadAll(r.Body)
+		self.altText = string(body)
+		return
+	}
+
+	r.ParseMultipartForm(1 << 20)
+	command := r.FormValue("command")
+	self.commands = append(self.commands, command)
+
+	switch command {
+	case "":
+		fmt.Fprint(w, `{"media_id":1,"size":3}`)
+	case "INIT":
+		fmt.Fprint(w, `{"media_id":2,"media_id_string":"2","expires_after_secs":3600}`)
+	case "APPEND":
+		index, _ := strconv.Atoi(r.FormValue("segment_index"))
+		if index == self.dropSegment && !self.dropped {
+			self.dropped = true
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		file, _, err := r.FormFile("media")
+		if err != nil {
+			http.Error(w, `{"errors":[{"code":324,"message":"no media"}]}`, 400)
+			return
+		}
+		self.segments[index], _ = ioutil.ReadAll(file)
+		w.WriteHeader(204)
+	case "FINALIZE":
+		fmt.Fprint(w, `{"media_id":2,"processing_info":{"state":"pending","check_after_secs":0}}`)
+	case "STATUS":
+		state := "succeeded"
+		if self.pendingChecks > 0 {
+			self.pendingChecks--
+			state = "in_progress"
+		}
+		fmt.Fprintf(w, `{"media_id":2,"processing_info":{"state":%q,"check_after_secs":0}}`, state)
+	}
+}
+
+func (self *tFakeUploadServer) uploaded() []byte {
+	var data []byte
+	for i := 0; i < len(self.segments); i++ {
+		data = append(data, self.segments[i]...)
+	}
+	return data
+}
+
+func newTestUploader(fake *tFakeUploadServer) (*Uploader, func()) {
+	server := httptest.NewServer(fake)
+	api := twitter.NewApi()
+	api.SetHTTPClient(server.Client())
+
+	uploader := NewUploader(api)
+	uploader.UploadUrl = server.URL + "/upload"
+	uploader.MetadataUrl = server.URL + "/metadata"
+	uploader.ChunkSize = 4
+	uploader.RetryWait = 0
+	return uploader, server.Close
+}
+
+func TestUploadSimpleImage(t *testing.T) {
+	fake := &tFakeUploadServer{segments: make(map[int][]byte), dropSegment: -1}
+	uploader, done := newTestUploader(fake)
+	defer done()
+
+	media, err := uploader.Upload(context.Background(), []byte("png"), "image/png")
+	if err != nil {
+		t.Fatalf("Upload: %s", err)
+	}
+	if media.MediaId != 1 || len(fake.commands) != 1 {
+		t.Errorf("expected one simple upload, got media %d after %v",
+			media.MediaId, fake.commands)
+	}
+}
+
+func TestUploadChunkedResumesAfterNetworkError(t *testing.T) {
+	fake := &tFakeUploadServer{
+		segments:      make(map[int][]byte),
+		dropSegment:   1,
+		pendingChecks: 1,
+	}
+	uploader, done := newTestUploader(fake)
+	defer done()
+
+	var progress []int64
+	uploader.Progress = func(sent, total int64) {
+		progress = append(progress, sent)
+	}
+
+	data := []byte("0123456789")
+	media, err := uploader.Upload(context.Background(), data, "video/mp4")
+	if err != nil {
+		t.Fatalf("Upload: %s", err)
+	}
+
+	if media.MediaId != 2 || media.ProcessingInfo.State != "succeeded" {
+		t.Errorf("got media %d in state %s, expected 2 succeeded",
+			media.MediaId, media.ProcessingInfo.State)
+	}
+	if !bytes.Equal(fake.uploaded(), data) {
+		t.Errorf("server got %q expected %q", fake.uploaded(), data)
+	}
+	if fmt.Sprint(progress) != "[4 8 10]" {
+		t.Errorf("progress: got %v expected [4 8 10]", progress)
+	}
+	if !fake.dropped {
+		t.Errorf("the connection was never dropped")
+	}
+}
+
+func TestResumeAfterGivingUp(t *testing.T) {
+	fake := &tFakeUploadServer{segments: make(map[int][]byte), dropSegment: 2}
+	uploader, done := newTestUploader(fake)
+	defer done()
+
+	data := bytes.NewReader([]byte("0123456789"))
+	uploader.MaxRetries = 0
+	_, err := uploader.UploadChunked(context.Background(), data, 10, "image/gif")
+
+	uerr, ok := err.(*UploadError)
+	if !ok {
+		t.Fatalf("expected an *UploadError, got %v", err)
+	}
+	if uerr.State.Segments != 2 {
+		t.Errorf("got %d acknowledged segments expected 2", uerr.State.Segments)
+	}
+
+	if _, err = uploader.Resume(context.Background(), data, uerr.State); err != nil {
+		t.Fatalf("Resume: %s", err)
+	}
+	if string(fake.uploaded()) != "0123456789" {
+		t.Errorf("server got %q", fake.uploaded())
+	}
+}
+
+func TestSetAltText(t *testing.T) {
+	fake := &tFakeUploadServer{segments: make(map[int][]byte)}
+	uploader, done := newTestUploader(fake)
+	defer done()
+
+	if err := uploader.SetAltText(context.Background(), 2, "a cat"); err != nil {
+		t.Fatalf("SetAltText: %s", err)
+	}
+	if fake.altText != `{"media_id":"2","alt_text":{"text":"a cat"}}` {
+		t.Errorf("server got %s", fake.altText)
+	}
+}
+
+func TestChunkSizeAndCancel(t *testing.T) {
+	fake := &tFakeUploadServer{segments: make(map[int][]byte), dropSegment: -1}
+	uploader, done := newTestUploader(fake)
+	defer done()
+
+	state := &UploadState{MediaId: 2, Size: 10}
+	if _, err := uploader.Resume(context.Background(), bytes.NewReader(nil), state); err == nil {
+		t.Errorf("Resume with a zero chunk size: got no error")
+	}
+
+	uploader.ChunkSize = 0
+	data := []byte("0123456789")
+	if _, err := uploader.UploadChunked(context.Background(), bytes.NewReader(data), 10, "video/mp4"); err != nil {
+		t.Fatalf("UploadChunked with the default chunk size: %s", err)
+	}
+	if len(fake.segments) != 1 || string(fake.uploaded()) != string(data) {
+		t.Errorf("server got %d segments %q expected one", len(fake.segments), fake.uploaded())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := uploader.retry(ctx, func() error {
+		cancel()
+		return fmt.Errorf("connection reset")
+	})
+	if err != context.Canceled {
+		t.Errorf("retry: got %v expected context.Canceled", err)
+	}
+}
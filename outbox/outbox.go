@@ -0,0 +1,407 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Package outbox queues statuses for posting later. The queue survives
+// restarts, failed posts are retried with exponential backoff and every
+// post carries an idempotency key so queuing it twice sends it once.
+//
+//    store, _ := outbox.NewFileStore("outbox.json")
+//    box, _ := outbox.Open(api, store)
+//    box.Enqueue(outbox.Post{Key: "release-1.2", Text: "1.2 is out",
+//      SendAt: release})
+//    go box.Run(ctx, time.Minute)
+//
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"twitter"
+)
+
+const (
+	kDefaultMaxAttempts = 8
+	kDefaultMinBackoff  = 30 * time.Second
+	kDefaultMaxBackoff  = time.Hour
+)
+
+type State int
+
+const (
+	// Waiting for its time or its next attempt
+	Pending State = iota
+	// Handed to the API. Items found in this state when the outbox is
+	// opened were interrupted and are sent again.
+	Sending
+	Sent
+	// Gave up after a permanent error or too many attempts
+	Failed
+	Canceled
+)
+
+var stateNames = []string{"pending", "sending", "sent", "failed", "canceled"}
+
+func (self State) String() string {
+	if self < 0 || int(self) >= len(stateNames) {
+		return fmt.Sprintf("State(%d)", int(self))
+	}
+	return stateNames[self]
+}
+
+func (self State) MarshalText() ([]byte, error) { return []byte(self.String()), nil }
+
+func (self *State) UnmarshalText(text []byte) error {
+	for i, name := range stateNames {
+		if name == string(text) {
+			*self = State(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("outbox: unknown state %q", text)
+}
+
+var ErrNotPending = errors.New("outbox: item is not pending")
+
+// A status to post
+type Post struct {
+	// Identifies the post. Enqueuing a post whose key is already queued
+	// does nothing. A random key is used if empty.
+	Key string
+
+	Text              string
+	InReplyToStatusId int64
+	MediaIds          []int64
+
+	// When to send the post, right away if zero
+	SendAt time.Time
+}
+
+// A queued post and how sending it went
+type Item struct {
+	Post
+
+	State    State
+	Attempts int
+
+	// When the next attempt is due after a failed one
+	NextAttempt time.Time
+
+	// Why the last attempt failed
+	LastError string `json:",omitempty"`
+
+	// Set once sent. StatusId stays 0 if Twitter rejected the post as a
+	// duplicate of one sent in an interrupted attempt.
+	StatusId int64 `json:",omitempty"`
+	SentAt   time.Time
+}
+
+// What an Outbox needs from the API, *twitter.Api satisfies it
+type Poster interface {
+	PostStatus(ctx context.Context, update twitter.StatusUpdate) (twitter.Status, error)
+}
+
+// A queue of posts backed by a Store. An Outbox may be used by several
+// goroutines.
+type Outbox struct {
+	api   Poster
+	store Store
+
+	// Attempts before an item fails for good
+	MaxAttempts int
+
+	// The wait after the first failed attempt, doubling with each further
+	// one up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Returns the current time, time.Now by default
+	Now func() time.Time
+
+	lock    sync.Mutex
+	sending sync.Mutex
+	items   []*Item
+	keys    map[string]*Item
+}
+
+// Loads the queue from store
+func Open(api Poster, store Store) (*Outbox, error) {
+	items, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	self := &Outbox{
+		api:         api,
+		store:       store,
+		MaxAttempts: kDefaultMaxAttempts,
+		MinBackoff:  kDefaultMinBackoff,
+		MaxBackoff:  kDefaultMaxBackoff,
+		Now:         time.Now,
+		keys:        make(map[string]*Item),
+	}
+
+	for _, item := range items {
+		if item.State == Sending {
+			item.State = Pending
+			item.LastError = "interrupted"
+		}
+		if self.keys[item.Key] == nil {
+			self.items = append(self.items, item)
+			self.keys[item.Key] = item
+		}
+	}
+	return self, nil
+}
+
+// Queues a post and returns its item. If an item with the same key exists
+// already it is returned instead and nothing is queued.
+func (self *Outbox) Enqueue(post Post) (Item, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if post.Key == "" {
+		post.Key = newKey()
+	}
+	if item, ok := self.keys[post.Key]; ok {
+		return *item, nil
+	}
+
+	item := &Item{Post: post}
+	self.items = append(self.items, item)
+	self.keys[post.Key] = item
+
+	if err := self.store.Save(self.items); err != nil {
+		self.items = self.items[:len(self.items)-1]
+		delete(self.keys, post.Key)
+		return Item{}, err
+	}
+	return *item, nil
+}
+
+// Returns the item with the given key
+func (self *Outbox) Item(key string) (Item, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if item, ok := self.keys[key]; ok {
+		return *item, true
+	}
+	return Item{}, false
+}
+
+// Returns every item in the order they were queued
+func (self *Outbox) Items() []Item {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	items := make([]Item, len(self.items))
+	for i, item := range self.items {
+		items[i] = *item
+	}
+	return items
+}
+
+// Keeps a pending item from being sent. Fails with ErrNotPending for
+// items that are being sent or are done.
+func (self *Outbox) Cancel(key string) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	item, ok := self.keys[key]
+	if !ok || item.State != Pending {
+		return ErrNotPending
+	}
+
+	item.State = Canceled
+	if err := self.store.Save(self.items); err != nil {
+		item.State = Pending
+		return err
+	}
+	return nil
+}
+
+// Removes the items sent before before and returns how many there were.
+// Sent items are kept until pruned, so the queue grows with every post;
+// their keys stop deduplicating once they are gone. Failed and canceled
+// items are kept for inspection.
+func (self *Outbox) Prune(before time.Time) (int, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	var kept []*Item
+	for _, item := range self.items {
+		if item.State != Sent || !item.SentAt.Before(before) {
+			kept = append(kept, item)
+		}
+	}
+
+	pruned := len(self.items) - len(kept)
+	if pruned == 0 {
+		return 0, nil
+	}
+	if err := self.store.Save(kept); err != nil {
+		return 0, err
+	}
+
+	for _, item := range self.items {
+		if item.State == Sent && item.SentAt.Before(before) {
+			delete(self.keys, item.Key)
+		}
+	}
+	self.items = kept
+	return pruned, nil
+}
+
+// Sends the items that are due, oldest first. Failed posts are recorded
+// on their items, the error is about saving the queue or ctx.
+func (self *Outbox) SendDue(ctx context.Context) error {
+	self.sending.Lock()
+	defer self.sending.Unlock()
+
+	for _, item := range self.due() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := self.send(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Calls SendDue every interval until ctx is done or saving the queue fails
+func (self *Outbox) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := self.SendDue(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (self *Outbox) due() []*Item {
+	var due []*Item
+	now := self.Now()
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, item := range self.items {
+		if item.State == Pending && !item.SendAt.After(now) && !item.NextAttempt.After(now) {
+			due = append(due, item)
+		}
+	}
+	return due
+}
+
+// Posts an item and records the outcome. The item is saved as Sending
+// first so a crash midway is noticed on the next Open.
+func (self *Outbox) send(ctx context.Context, item *Item) error {
+	self.lock.Lock()
+	if item.State != Pending {
+		// Canceled meanwhile
+		self.lock.Unlock()
+		return nil
+	}
+	item.State = Sending
+	item.Attempts++
+	err := self.store.Save(self.items)
+	if err != nil {
+		// Still due, the next SendDue tries again
+		item.State = Pending
+		item.Attempts--
+	}
+	update := twitter.StatusUpdate{
+		Status:            item.Text,
+		InReplyToStatusId: item.InReplyToStatusId,
+		MediaIds:          item.MediaIds,
+	}
+	self.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	status, err := self.api.PostStatus(ctx, update)
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := self.Now()
+	switch {
+	case err == nil:
+		item.State = Sent
+		item.StatusId = status.GetId()
+		item.SentAt = now
+		item.LastError = ""
+	case errors.Is(err, twitter.ErrDuplicateStatus):
+		item.State = Sent
+		item.SentAt = now
+	case ctx.Err() != nil:
+		// Not the post's fault, try again as if nothing happened
+		item.State = Pending
+		item.Attempts--
+	case isPermanent(err) || item.Attempts >= self.MaxAttempts:
+		item.State = Failed
+		item.LastError = err.Error()
+	default:
+		item.State = Pending
+		item.NextAttempt = now.Add(self.backoff(item.Attempts))
+		item.LastError = err.Error()
+	}
+
+	return self.store.Save(self.items)
+}
+
+func (self *Outbox) backoff(attempts int) time.Duration {
+	wait := self.MinBackoff
+	for i := 1; i < attempts && wait < self.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > self.MaxBackoff {
+		wait = self.MaxBackoff
+	}
+	return wait
+}
+
+// Client errors other than rate limiting won't go away by retrying
+func isPermanent(err error) bool {
+	var twitterErr *twitter.TwitterError
+	if !errors.As(err, &twitterErr) {
+		return false
+	}
+
+	code := twitterErr.GetStatusCode()
+	return code >= 400 && code < 500 && code != 420 && code != 429
+}
+
+func newKey() string {
+	key := make([]byte, 16)
+	rand.Read(key)
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,198 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"twitter"
+)
+
+type tFakePoster struct {
+	errs  []error
+	posts []twitter.StatusUpdate
+}
+
+func (self *tFakePoster) PostStatus(ctx context.Context,
+	update twitter.StatusUpdate) (twitter.Status, error) {
+	self.posts = append(self.posts, update)
+	if len(self.errs) > 0 {
+		err := self.errs[0]
+		self.errs = self.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return twitter.ParseStatus([]byte(fmt.Sprintf(`{"id":%d}`, 100+len(self.posts))))
+}
+
+func openTestOutbox(t *testing.T, poster Poster, path string, now *time.Time) *Outbox {
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %s", err)
+	}
+	box, err := Open(poster, store)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	box.Now = func() time.Time { return *now }
+	return box
+}
+
+func TestScheduleRetryAndReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	poster := &tFakePoster{errs: []error{errors.New("connection reset")}}
+
+	box := openTestOutbox(t, poster, path, &now)
+	box.Enqueue(Post{Key: "a", Text: "now"})
+	box.Enqueue(Post{Key: "b", Text: "later", SendAt: now.Add(time.Hour)})
+	if item, _ := box.Enqueue(Post{Key: "a", Text: "again"}); item.Text != "now" {
+		t.Errorf("Enqueue with a known key: got %q", item.Text)
+	}
+
+	if err := box.SendDue(ctx); err != nil {
+		t.Fatalf("SendDue: %s", err)
+	}
+	item, _ := box.Item("a")
+	if item.State != Pending || item.NextAttempt != now.Add(box.MinBackoff) {
+		t.Errorf("after a failure: got %s, next attempt %s", item.State, item.NextAttempt)
+	}
+
+	// A fresh outbox on the same file picks up where this one stopped
+	now = now.Add(2 * time.Hour)
+	box = openTestOutbox(t, poster, path, &now)
+	if err := box.SendDue(ctx); err != nil {
+		t.Fatalf("SendDue: %s", err)
+	}
+
+	items := box.Items()
+	if len(items) != 2 || items[0].State != Sent || items[1].State != Sent {
+		t.Fatalf("got items %+v", items)
+	}
+	if items[0].Attempts != 2 || items[0].StatusId != 102 || items[1].StatusId != 103 {
+		t.Errorf("got attempts %d, ids %d and %d", items[0].Attempts,
+			items[0].StatusId, items[1].StatusId)
+	}
+	if len(poster.posts) != 3 {
+		t.Errorf("posted %d times expected 3", len(poster.posts))
+	}
+}
+
+func TestInterruptedSendIsRetried(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	now := time.Now()
+	poster := &tFakePoster{}
+
+	store, _ := NewFileStore(path)
+	store.Save([]*Item{{Post: Post{Key: "a", Text: "hi"}, State: Sending, Attempts: 1}})
+
+	box := openTestOutbox(t, poster, path, &now)
+	if item, _ := box.Item("a"); item.State != Pending {
+		t.Fatalf("got %s expected pending", item.State)
+	}
+	if err := box.Cancel("a"); err != nil {
+		t.Fatalf("Cancel: %s", err)
+	}
+	box.SendDue(context.Background())
+	if len(poster.posts) != 0 {
+		t.Errorf("canceled item was posted")
+	}
+	if err := box.Cancel("a"); err != ErrNotPending {
+		t.Errorf("Cancel twice: got %v", err)
+	}
+}
+
+// Keeps items in memory and fails Save while fail is set
+type tFlakyStore struct {
+	items []*Item
+	fail  bool
+}
+
+func (self *tFlakyStore) Load() ([]*Item, error) { return self.items, nil }
+
+func (self *tFlakyStore) Save(items []*Item) error {
+	if self.fail {
+		return errors.New("disk full")
+	}
+	self.items = items
+	return nil
+}
+
+func TestFailedSaveLeavesItemPending(t *testing.T) {
+	ctx := context.Background()
+	store := &tFlakyStore{}
+	poster := &tFakePoster{}
+	box, err := Open(poster, store)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	box.Enqueue(Post{Key: "a", Text: "hi"})
+
+	store.fail = true
+	if err := box.SendDue(ctx); err == nil {
+		t.Fatalf("SendDue: got no error expected the save error")
+	}
+	if err := box.Cancel("a"); err == nil {
+		t.Fatalf("Cancel: got no error expected the save error")
+	}
+	if item, _ := box.Item("a"); item.State != Pending || item.Attempts != 0 || len(poster.posts) != 0 {
+		t.Errorf("after failed saves: got %s after %d attempts, %d posts", item.State,
+			item.Attempts, len(poster.posts))
+	}
+
+	store.fail = false
+	if err := box.SendDue(ctx); err != nil {
+		t.Fatalf("SendDue: %s", err)
+	}
+	if item, _ := box.Item("a"); item.State != Sent || item.Attempts != 1 {
+		t.Errorf("after retrying: got %s after %d attempts", item.State, item.Attempts)
+	}
+}
+
+func TestPruneDropsOldSentItems(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := &tFlakyStore{}
+	box, _ := Open(&tFakePoster{errs: []error{nil, &twitter.TwitterError{}}}, store)
+	box.Now = func() time.Time { return now }
+	box.MaxAttempts = 1
+
+	box.Enqueue(Post{Key: "old", Text: "old"})
+	box.Enqueue(Post{Key: "failed", Text: "failed"})
+	box.SendDue(ctx)
+
+	now = now.Add(48 * time.Hour)
+	box.Enqueue(Post{Key: "new", Text: "new"})
+	box.SendDue(ctx)
+
+	pruned, err := box.Prune(now.Add(-24 * time.Hour))
+	if err != nil || pruned != 1 {
+		t.Fatalf("Prune: got %d, %v expected 1", pruned, err)
+	}
+	if _, ok := box.Item("old"); ok {
+		t.Errorf("old item still there")
+	}
+	if len(box.Items()) != 2 || len(store.items) != 2 {
+		t.Errorf("got %d items, %d stored expected 2", len(box.Items()), len(store.items))
+	}
+}
@@ -0,0 +1,76 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package outbox
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Keeps the items of an Outbox across restarts
+type Store interface {
+	// Returns every item saved last, none if nothing was saved yet
+	Load() ([]*Item, error)
+
+	// Replaces the saved items
+	Save(items []*Item) error
+}
+
+// A Store keeping all items in one JSON file
+type FileStore struct {
+	path string
+}
+
+// Creates a FileStore writing to path, creating its directory if needed
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{path}, nil
+}
+
+func (self *FileStore) Load() ([]*Item, error) {
+	var items []*Item
+
+	data, err := ioutil.ReadFile(self.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Writes to a temporary file first so a crash never leaves a half written
+// queue behind
+func (self *FileStore) Save(items []*Item) error {
+	data, err := json.MarshalIndent(items, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err = ioutil.WriteFile(self.path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(self.path+".tmp", self.path)
+}
@@ -0,0 +1,238 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// The most a status may weigh, see TweetLength
+	kMaxTweetLength = 280
+
+	// What a link weighs once Twitter wraps it in t.co
+	kUrlLength = 23
+)
+
+var urlPattern = regexp.MustCompile(`https?://\S+`)
+
+// Returned by PostThread for text that is empty or only white space
+var ErrEmptyThread = errors.New(kErr + "thread text is empty")
+
+// Options of PostThread and SplitThread
+type ThreadOptions struct {
+	// The status the first part replies to, 0 to start a new conversation
+	InReplyToStatusId int64
+
+	// Appends "1/5", "2/5" and so on to the parts
+	Number bool
+
+	// The most a part may weigh, 280 if 0
+	MaxLength int
+
+	// The ids PostThread returned for the parts posted before it failed.
+	// Those parts are skipped and the rest replies to the last of them.
+	// The text and options must be the same as in the failed call.
+	Resume []int64
+}
+
+// Returned by PostThread when a part couldn't be posted
+type ThreadError struct {
+	// The ids of the parts that made it, to pass as ThreadOptions.Resume
+	Posted []int64
+	Err    error
+}
+
+func (self *ThreadError) Error() string {
+	return fmt.Sprintf("thread failed after %d parts: %s", len(self.Posted), self.Err)
+}
+
+func (self *ThreadError) Unwrap() error { return self.Err }
+
+// Returns the length of text the way Twitter counts it against the limit:
+// links count 23, most Latin, Greek and Cyrillic characters count 1 and
+// everything else, such as CJK characters and emoji, counts 2.
+func TweetLength(text string) int {
+	length := 0
+	for _, part := range urlPattern.Split(text, -1) {
+		for _, r := range part {
+			length += runeWeight(r)
+		}
+	}
+	return length + kUrlLength*len(urlPattern.FindAllStringIndex(text, -1))
+}
+
+func runeWeight(r rune) int {
+	switch {
+	case r <= 0x10ff, r >= 0x2000 && r <= 0x200d, r >= 0x2010 && r <= 0x201f,
+		r >= 0x2032 && r <= 0x2037:
+		return 1
+	}
+	return 2
+}
+
+// Splits text into parts short enough for a status each. Parts end at
+// sentence ends where possible, then between words. Runs of white space,
+// line breaks included, become single spaces. Numbering may take at most
+// half of MaxLength, a shorter MaxLength is an error.
+func SplitThread(text string, opts ThreadOptions) ([]string, error) {
+	limit := opts.MaxLength
+	if limit <= 0 {
+		limit = kMaxTweetLength
+	}
+	if !opts.Number {
+		return packThread(threadTokens(text, limit), limit), nil
+	}
+
+	// Make room for " 1/5", which depends on the number of parts
+	for digits := 1; ; digits++ {
+		numbering := 2*digits + 2
+		room := limit - numbering
+		if room < numbering {
+			return nil, fmt.Errorf("%sMaxLength %d leaves no room for numbered parts",
+				kErr, limit)
+		}
+
+		parts := packThread(threadTokens(text, room), room)
+		total := strconv.Itoa(len(parts))
+		if len(total) > digits {
+			continue
+		}
+
+		for i := range parts {
+			parts[i] += " " + strconv.Itoa(i+1) + "/" + total
+		}
+		return parts, nil
+	}
+}
+
+// Breaks text into sentences, and sentences that don't fit into limit
+// into words. Words that still don't fit are cut.
+func threadTokens(text string, limit int) []string {
+	var tokens, sentence []string
+
+	flush := func() {
+		if len(sentence) == 0 {
+			return
+		}
+		if joined := strings.Join(sentence, " "); TweetLength(joined) <= limit {
+			tokens = append(tokens, joined)
+		} else {
+			for _, word := range sentence {
+				tokens = append(tokens, cutWord(word, limit)...)
+			}
+		}
+		sentence = nil
+	}
+
+	for _, word := range strings.Fields(text) {
+		sentence = append(sentence, word)
+		if strings.ContainsAny(word[len(word)-1:], ".!?") {
+			flush()
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func cutWord(word string, limit int) []string {
+	var pieces []string
+	for TweetLength(word) > limit {
+		length, i := 0, 0
+		for i < len(word) {
+			r, size := utf8.DecodeRuneInString(word[i:])
+			if length+runeWeight(r) > limit {
+				break
+			}
+			length += runeWeight(r)
+			i += size
+		}
+		if i == 0 {
+			_, i = utf8.DecodeRuneInString(word)
+		}
+		pieces = append(pieces, word[:i])
+		word = word[i:]
+	}
+	return append(pieces, word)
+}
+
+// Joins tokens into as few parts of at most limit as possible, keeping
+// their order
+func packThread(tokens []string, limit int) []string {
+	var parts []string
+	part := ""
+
+	for _, token := range tokens {
+		if part != "" && TweetLength(part+" "+token) <= limit {
+			part += " " + token
+			continue
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+		part = token
+	}
+	if part != "" {
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// Posts text split by SplitThread, each part replying to the one before.
+// Returns the ids of all parts in order. If a part fails a *ThreadError
+// carries the ids posted so far; pass them as opts.Resume to carry on.
+// Text without anything to post is ErrEmptyThread.
+func (self *Api) PostThread(ctx context.Context, text string, opts ThreadOptions) ([]int64, error) {
+	parts, err := SplitThread(text, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, ErrEmptyThread
+	}
+	ids := append([]int64(nil), opts.Resume...)
+
+	replyTo := opts.InReplyToStatusId
+	if len(ids) > 0 {
+		replyTo = ids[len(ids)-1]
+	}
+
+	for i := len(ids); i < len(parts); i++ {
+		update := StatusUpdate{
+			Status:                    parts[i],
+			InReplyToStatusId:         replyTo,
+			AutoPopulateReplyMetadata: replyTo != 0,
+		}
+
+		status, err := self.PostStatus(ctx, update)
+		if err != nil {
+			return ids, &ThreadError{Posted: ids, Err: err}
+		}
+
+		replyTo = status.GetId()
+		ids = append(ids, replyTo)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,135 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTweetLength(t *testing.T) {
+	tests := []struct {
+		text   string
+		length int
+	}{
+		{"hello", 5},
+		{"日本語", 6},
+		{"see https://golang.org/doc/go1.html now", 4 + 23 + 4},
+		{"“quoted” — ok", 13},
+	}
+
+	for _, test := range tests {
+		if got := TweetLength(test.text); got != test.length {
+			t.Errorf("TweetLength(%q): got %d expected %d", test.text, got, test.length)
+		}
+	}
+}
+
+func TestSplitThread(t *testing.T) {
+	text := "First sentence here. Second one is a bit longer! Third?"
+
+	parts, _ := SplitThread(text, ThreadOptions{MaxLength: 30})
+	if strings.Join(parts, "|") != "First sentence here.|Second one is a bit longer!|Third?" {
+		t.Errorf("got %q", parts)
+	}
+
+	parts, _ = SplitThread(text, ThreadOptions{MaxLength: 32, Number: true})
+	for _, part := range parts {
+		if TweetLength(part) > 32 {
+			t.Errorf("part %q too long", part)
+		}
+	}
+	if len(parts) != 3 || parts[2] != "Third? 3/3" {
+		t.Errorf("got %q", parts)
+	}
+
+	parts, _ = SplitThread("a b c d e f g h i j k", ThreadOptions{MaxLength: 8, Number: true})
+	if len(parts) != 6 || parts[0] != "a b 1/6" {
+		t.Errorf("got %q", parts)
+	}
+
+	parts, _ = SplitThread(strings.Repeat("x", 25), ThreadOptions{MaxLength: 10})
+	if strings.Join(parts, "|") != "xxxxxxxxxx|xxxxxxxxxx|xxxxx" {
+		t.Errorf("got %q", parts)
+	}
+}
+
+func TestSplitThreadRejectsShortMaxLength(t *testing.T) {
+	if parts, err := SplitThread("a b c", ThreadOptions{MaxLength: 6, Number: true}); err == nil {
+		t.Errorf("MaxLength 6: got %q expected an error", parts)
+	}
+
+	// Twelve parts need two digits, which no longer fit into half of 11
+	text := strings.Repeat("abcde ", 12)
+	if parts, err := SplitThread(text, ThreadOptions{MaxLength: 11, Number: true}); err == nil {
+		t.Errorf("MaxLength 11: got %q expected an error", parts)
+	}
+
+	posts := 0
+	api := newFakeApi(&tFakeTransport{status: 200, reply: func(req *http.Request) string {
+		posts++
+		return `{"id":1}`
+	}})
+	if _, err := api.PostThread(context.Background(), "a b c", ThreadOptions{MaxLength: 3, Number: true}); err == nil || posts != 0 {
+		t.Errorf("PostThread: got %v after %d posts expected an error and none", err, posts)
+	}
+
+	for _, text := range []string{"", " \n\t "} {
+		ids, err := api.PostThread(context.Background(), text, ThreadOptions{})
+		if err != ErrEmptyThread || ids != nil || posts != 0 {
+			t.Errorf("PostThread(%q): got %v, %v after %d posts expected ErrEmptyThread", text, ids, err, posts)
+		}
+	}
+}
+
+func TestPostThreadResumes(t *testing.T) {
+	var replies []string
+	fail := 3
+	api := newFakeApi(&tFakeTransport{status: 200})
+	api.httpClient.Transport.(*tFakeTransport).reply = func(req *http.Request) string {
+		req.ParseForm()
+		replies = append(replies, req.PostForm.Get("in_reply_to_status_id"))
+		if len(replies) == fail {
+			return `{"id":`
+		}
+		return `{"id":` + strconv.Itoa(100+len(replies)) + `}`
+	}
+
+	text := "One. Two. Three. Four."
+	opts := ThreadOptions{MaxLength: 6, InReplyToStatusId: 50}
+
+	ids, err := api.PostThread(context.Background(), text, opts)
+	var threadErr *ThreadError
+	if !errors.As(err, &threadErr) || len(threadErr.Posted) != 2 {
+		t.Fatalf("PostThread: got %v", err)
+	}
+
+	opts.Resume = ids
+	ids, err = api.PostThread(context.Background(), text, opts)
+	if err != nil {
+		t.Fatalf("PostThread: %s", err)
+	}
+	if len(ids) != 4 || ids[3] != 105 {
+		t.Errorf("got ids %v", ids)
+	}
+	if strings.Join(replies, ",") != "50,101,102,102,104" {
+		t.Errorf("got replies to %v", replies)
+	}
+}
@@ -5,19 +5,32 @@ type RateLimit interface {
   GetHourlyLimit() int
   GetResetTimeInSeconds() int64
   GetResetTime() string
+  MarshalJSON() ([]byte, error)
+  RawFields
 }
 
 type tTwitterRateLimit struct {
-  Remaining_hits int
-  Hourly_limit int
-  Reset_time_in_seconds int64
-  Reset_time string
+  Remaining_hits int `json:"remaining_hits"`
+  Hourly_limit int `json:"hourly_limit"`
+  Reset_time_in_seconds int64 `json:"reset_time_in_seconds"`
+  Reset_time string `json:"reset_time"`
+  tJsonFields
 }
 
 type tTwitterRateLimitDummy struct {
   Object tTwitterRateLimit
 }
 
+func (self *tTwitterRateLimit) UnmarshalJSON(data []byte) error {
+  type plain tTwitterRateLimit
+  return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterRateLimit) MarshalJSON() ([]byte, error) {
+  type plain tTwitterRateLimit
+  return self.marshalFields((*plain)(self))
+}
+
 func (self *tTwitterRateLimit) GetRemainingHits() int {
   return self.Remaining_hits
 }
@@ -0,0 +1,102 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// A search the authenticated user saved. GetQuery is the plain query, ready
+// to be passed to Search.
+type SavedSearch interface {
+	GetId() int64
+	GetName() string
+	GetQuery() string
+	GetPosition() string
+	GetCreatedAt() string
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+type tTwitterSavedSearch struct {
+	Id         int64  `json:"id"`
+	Name       string `json:"name"`
+	Query      string `json:"query"`
+	Position   string `json:"position"`
+	Created_at string `json:"created_at"`
+	tJsonFields
+}
+
+func (self *tTwitterSavedSearch) UnmarshalJSON(data []byte) error {
+	type plain tTwitterSavedSearch
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterSavedSearch) MarshalJSON() ([]byte, error) {
+	type plain tTwitterSavedSearch
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterSavedSearch) GetId() int64 { return self.Id }
+
+func (self *tTwitterSavedSearch) GetName() string { return self.Name }
+
+func (self *tTwitterSavedSearch) GetQuery() string { return self.Query }
+
+func (self *tTwitterSavedSearch) GetPosition() string { return self.Position }
+
+func (self *tTwitterSavedSearch) GetCreatedAt() string { return self.Created_at }
+
+// Returns the saved searches of the authenticated user
+func (self *Api) GetSavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	var list []tTwitterSavedSearch
+
+	if err := self.callJson(ctx, "GET", _QUERY_SAVEDSEARCHES, url.Values{}, &list); err != nil {
+		return nil, err
+	}
+
+	searches := make([]SavedSearch, len(list))
+	for i := range list {
+		searches[i] = &list[i]
+	}
+	return searches, nil
+}
+
+// Returns one of the saved searches of the authenticated user
+func (self *Api) GetSavedSearch(ctx context.Context, id int64) (SavedSearch, error) {
+	return self.savedSearchCall(ctx, "GET", fmt.Sprintf(_QUERY_SAVEDSEARCH, id), url.Values{})
+}
+
+// Saves a search query for the authenticated user
+func (self *Api) CreateSavedSearch(ctx context.Context, query string) (SavedSearch, error) {
+	return self.savedSearchCall(ctx, "POST", _QUERY_CREATESAVEDSEARCH, url.Values{"query": {query}})
+}
+
+// Deletes a saved search and returns it
+func (self *Api) DestroySavedSearch(ctx context.Context, id int64) (SavedSearch, error) {
+	return self.savedSearchCall(ctx, "POST", fmt.Sprintf(_QUERY_DESTROYSAVEDSEARCH, id), url.Values{})
+}
+
+func (self *Api) savedSearchCall(ctx context.Context, method, url_ string,
+	params url.Values) (SavedSearch, error) {
+	search := new(tTwitterSavedSearch)
+	if err := self.callJson(ctx, method, url_, params, search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
@@ -0,0 +1,44 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCreateSavedSearch(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body: `{"created_at":"Fri Nov 04 18:46:41 +0000 2011","id":62353170,` +
+			`"id_str":"62353170","name":"@twitterapi","position":null,"query":"@twitterapi"}`,
+		check: func(req *http.Request) {
+			req.ParseForm()
+			if req.Method != "POST" || req.PostForm.Get("query") != "@twitterapi" {
+				t.Errorf("got %s with %v", req.Method, req.PostForm)
+			}
+		},
+	})
+
+	search, err := api.CreateSavedSearch(context.Background(), "@twitterapi")
+	if err != nil {
+		t.Fatalf("CreateSavedSearch: %s", err)
+	}
+	if search.GetId() != 62353170 || search.GetQuery() != "@twitterapi" {
+		t.Errorf("got id %d query %q", search.GetId(), search.GetQuery())
+	}
+}
@@ -1,5 +1,7 @@
 package twitter
 
+import "encoding/json"
+
 type SearchResult interface {
   GetCreatedAt() string
   GetFromUser() string
@@ -7,9 +9,12 @@ type SearchResult interface {
   GetText() string
   GetId() int64
   GetFromUserId() int64
-  GetGeo() string
+  // Where the status was sent from, nil if the author didn't share it
+  GetGeo() *Coordinates
   GetIsoLanguageCode() string
   GetSource() string
+  MarshalJSON() ([]byte, error)
+  RawFields
 }
 
 type tTwitterSearch struct {
@@ -17,23 +22,45 @@ type tTwitterSearch struct {
 }
 
 type tTwitterSearchResult struct {
-  Profile_image_url string
-  Created_at        string
-  From_user         string
-  To_user_id        int64
-  Text              string
-  Id                int64
-  From_user_id      int64
-  Geo               string
-  Iso_language_code string
-  Source            string
-  Error             string
+  Profile_image_url string         `json:"profile_image_url"`
+  Created_at        string         `json:"created_at"`
+  From_user         string         `json:"from_user"`
+  To_user_id        int64          `json:"to_user_id"`
+  Text              string         `json:"text"`
+  Id                int64          `json:"id"`
+  From_user_id      int64          `json:"from_user_id"`
+  Geo               *tLatLongPoint `json:"geo"`
+  Iso_language_code string         `json:"iso_language_code"`
+  Source            string         `json:"source"`
+  Error             string         `json:"error,omitempty"`
+  tJsonFields
 }
 
 type tTwitterSearchDummy struct {
   Object tTwitterSearch
 }
 
+// Creates a SearchResult from its JSON representation, as returned by the
+// Twitter search API or by json.Marshal on a SearchResult. Unknown members
+// are kept and written back out when the result is marshaled again.
+func ParseSearchResult(data []byte) (SearchResult, error) {
+  result := new(tTwitterSearchResult)
+  if err := json.Unmarshal(data, result); err != nil {
+    return nil, err
+  }
+  return result, nil
+}
+
+func (self *tTwitterSearchResult) UnmarshalJSON(data []byte) error {
+  type plain tTwitterSearchResult
+  return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterSearchResult) MarshalJSON() ([]byte, error) {
+  type plain tTwitterSearchResult
+  return self.marshalFields((*plain)(self))
+}
+
 func (self *tTwitterSearchResult) GetError() string {
   return self.Error
 }
@@ -62,12 +89,12 @@ func (self *tTwitterSearchResult) GetFromUserId() int64 {
   return self.From_user_id
 }
 
-func (self *tTwitterSearchResult) GetGeo() string {
-  return self.Geo
+func (self *tTwitterSearchResult) GetGeo() *Coordinates {
+  return (*Coordinates)(self.Geo)
 }
 
 func (self *tTwitterSearchResult) GetIsoLanguageCode() string {
-  return self.Geo
+  return self.Iso_language_code
 }
 
 func (self *tTwitterSearchResult) GetSource() string {
@@ -15,6 +15,8 @@
 //
 package twitter
 
+import "encoding/json"
+
 type Status interface {
   GetCreatedAt() string
   GetCreatedAtInSeconds() int64
@@ -26,7 +28,13 @@ type Status interface {
   GetInReplyToUserId() int64
   GetNow() int
   GetUser() User
+  // Where the status was sent from, nil if the author didn't share it
+  GetCoordinates() *Coordinates
+  // The place the status is about or was sent from, nil if none
+  GetPlace() Place
   setUser(user User)
+  MarshalJSON() ([]byte, error)
+  RawFields
 }
 
 type errorSource interface {
@@ -37,17 +45,20 @@ type errorSource interface {
 // the naming is odd so that
 // json.Unmarshal can do its thing properly
 type tTwitterStatus struct {
-  Text                    string
-  Created_at              string
-  Favorited               bool
-  Id                      int64
-  In_reply_to_screen_name string
-  In_reply_to_status_id   int64
-  In_reply_to_user_id     int64
-  Error                   string
-  User                    *tTwitterUser
+  Text                    string         `json:"text"`
+  Created_at              string         `json:"created_at"`
+  Favorited               bool           `json:"favorited"`
+  Id                      int64          `json:"id"`
+  In_reply_to_screen_name string         `json:"in_reply_to_screen_name"`
+  In_reply_to_status_id   int64          `json:"in_reply_to_status_id"`
+  In_reply_to_user_id     int64          `json:"in_reply_to_user_id"`
+  Error                   string         `json:"error,omitempty"`
+  User                    *tTwitterUser  `json:"user,omitempty"`
+  Coordinates             *Coordinates   `json:"coordinates,omitempty"`
+  Place                   *tTwitterPlace `json:"place,omitempty"`
   now                     int
   createdAtSeconds        int64
+  tJsonFields
 }
 
 type tTwitterStatusDummy struct {
@@ -60,13 +71,55 @@ type tTwitterTimelineDummy struct {
 
 func newEmptyTwitterStatus() *tTwitterStatus { return new(tTwitterStatus) }
 
-func (self *tTwitterStatus) GetError() string { return self.Error }
+// Creates a Status from its JSON representation, as returned by the
+// Twitter API or by json.Marshal on a Status. Unknown members are kept
+// and written back out when the Status is marshaled again.
+func ParseStatus(data []byte) (Status, error) {
+  status := newEmptyTwitterStatus()
+  if err := json.Unmarshal(data, status); err != nil {
+    return nil, err
+  }
+  return status, nil
+}
+
+func (self *tTwitterStatus) UnmarshalJSON(data []byte) error {
+  type plain tTwitterStatus
+  return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterStatus) MarshalJSON() ([]byte, error) {
+  type plain tTwitterStatus
+  unlock := self.read()
+  status := *self
+  // GetUser links the user back to us, don't follow that cycle
+  if status.User != nil && status.User.Status == self {
+    user := *status.User
+    user.Status = nil
+    status.User = &user
+  }
+  unlock()
+  return status.marshalFields((*plain)(&status))
+}
+
+func (self *tTwitterStatus) GetError() string {
+  defer self.read()()
+  return self.Error
+}
 
 func (self *tTwitterStatus) GetCreatedAt() string {
+  defer self.read()()
   return self.Created_at
 }
 
 func (self *tTwitterStatus) GetUser() User {
+  // Stored statuses are linked to their author by the EntityStore
+  if self.shared != nil {
+    defer self.read()()
+    if self.User == nil {
+      return newEmptyTwitterUser()
+    }
+    return self.User
+  }
   if self.User == nil {
     self.User = newEmptyTwitterUser()
   }
@@ -74,35 +127,63 @@ func (self *tTwitterStatus) GetUser() User {
   return self.User
 }
 
+func (self *tTwitterStatus) GetCoordinates() *Coordinates {
+  defer self.read()()
+  return self.Coordinates
+}
+
+func (self *tTwitterStatus) GetPlace() Place {
+  defer self.read()()
+  if self.Place == nil {
+    return nil
+  }
+  return self.Place
+}
+
 func (self *tTwitterStatus) setUser(user User) {
   self.User = user.(*tTwitterUser)
 }
 
 func (self *tTwitterStatus) GetCreatedAtInSeconds() int64 {
-  if self.createdAtSeconds == 0 {
+  defer self.read()()
+  // The EntityStore fills it in for stored statuses
+  if self.createdAtSeconds == 0 && self.shared == nil {
     self.createdAtSeconds = int64(parseTwitterDate(self.Created_at).Second())
   }
   return self.createdAtSeconds;
 }
 
 func (self *tTwitterStatus) GetFavorited() bool {
+  defer self.read()()
   return self.Favorited
 }
 
-func (self *tTwitterStatus) GetId() int64 { return self.Id }
+func (self *tTwitterStatus) GetId() int64 {
+  defer self.read()()
+  return self.Id
+}
 
 func (self *tTwitterStatus) GetInReplyToScreenName() string {
+  defer self.read()()
   return self.In_reply_to_screen_name
 }
 
-func (self *tTwitterStatus) GetText() string { return self.Text }
+func (self *tTwitterStatus) GetText() string {
+  defer self.read()()
+  return self.Text
+}
 
 func (self *tTwitterStatus) GetInReplyToStatusId() int64 {
+  defer self.read()()
   return self.In_reply_to_status_id
 }
 
 func (self *tTwitterStatus) GetInReplyToUserId() int64 {
+  defer self.read()()
   return self.In_reply_to_user_id
 }
 
-func (self *tTwitterStatus) GetNow() int { return self.now }
+func (self *tTwitterStatus) GetNow() int {
+  defer self.read()()
+  return self.now
+}
@@ -0,0 +1,283 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Package thread reconstructs conversations from the in_reply_to links of
+// statuses. Ancestors are fetched one by one, replies are found by
+// searching for statuses sent to the participants and, optionally, in the
+// authenticated user's mentions.
+//
+//    builder := thread.NewBuilder(api)
+//    tree, err := builder.BuildFromId(ctx, 1234)
+//    tree.Walk(func(node *thread.Node) { ... })
+//
+package thread
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"twitter"
+)
+
+const (
+	kDefaultMaxAncestors = 100
+	kDefaultSearchPages  = 5
+	kSearchPage          = 100
+)
+
+// What a Builder needs from the API, *twitter.Api satisfies it
+type Source interface {
+	ShowStatus(ctx context.Context, id int64) (twitter.Status, error)
+	SearchStatuses(ctx context.Context, query string,
+		opts twitter.TimelineOptions) ([]twitter.Status, error)
+	GetMentions(ctx context.Context, opts twitter.TimelineOptions) ([]twitter.Status, error)
+}
+
+// Builds conversation trees. Fetched statuses are cached, so building the
+// trees of statuses from the same conversation only fetches what's new.
+// A Builder may be used by several goroutines.
+type Builder struct {
+	api Source
+
+	// How far up the chain of replies to go
+	MaxAncestors int
+
+	// How many pages of search results to read per participant
+	SearchPages int
+
+	// Whether to look for replies in the authenticated user's mentions,
+	// which holds replies search misses. Needs an authenticated Api.
+	Mentions bool
+
+	lock     sync.Mutex
+	statuses map[int64]twitter.Status
+	missing  map[int64]bool
+}
+
+func NewBuilder(api Source) *Builder {
+	return &Builder{
+		api:          api,
+		MaxAncestors: kDefaultMaxAncestors,
+		SearchPages:  kDefaultSearchPages,
+		Mentions:     true,
+		statuses:     make(map[int64]twitter.Status),
+		missing:      make(map[int64]bool),
+	}
+}
+
+var errMissing = errors.New("thread: status unavailable")
+
+// Fetches a status and builds the tree of its conversation
+func (self *Builder) BuildFromId(ctx context.Context, id int64) (*ConversationTree, error) {
+	status, err := self.fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return self.Build(ctx, status)
+}
+
+// Builds the tree of the conversation status is part of
+func (self *Builder) Build(ctx context.Context, status twitter.Status) (*ConversationTree, error) {
+	tree := newTree()
+	self.remember(status)
+
+	tree.Focus = tree.node(status.GetId())
+	tree.Focus.Status = status
+
+	if err := self.addAncestors(ctx, tree); err != nil {
+		return nil, err
+	}
+	if err := self.addReplies(ctx, tree); err != nil {
+		return nil, err
+	}
+
+	tree.setDepths()
+	return tree, nil
+}
+
+// Follows the in_reply_to links up from the focus. The walk stops at the
+// first status that can't be fetched, which becomes a missing root.
+func (self *Builder) addAncestors(ctx context.Context, tree *ConversationTree) error {
+	node := tree.Focus
+	tree.Root = node
+
+	for i := 0; i < self.MaxAncestors; i++ {
+		parentId := node.Status.GetInReplyToStatusId()
+		if parentId == 0 {
+			break
+		}
+
+		parent := tree.node(parentId)
+		tree.link(parent, node)
+		tree.Root = parent
+
+		status, err := self.fetch(ctx, parentId)
+		if err == errMissing {
+			parent.Missing = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		parent.Status = status
+		node = parent
+	}
+
+	return nil
+}
+
+// Searches for statuses sent to each participant after the root, and
+// through the mentions, until no more replies turn up
+func (self *Builder) addReplies(ctx context.Context, tree *ConversationTree) error {
+	searched := make(map[string]bool)
+	sinceId := tree.Root.Id - 1
+	mentions := self.Mentions
+
+	for {
+		var found []twitter.Status
+
+		for _, node := range tree.Nodes() {
+			if node.Status == nil {
+				continue
+			}
+			author := node.Status.GetUser().GetScreenName()
+			if author == "" || searched[author] {
+				continue
+			}
+			searched[author] = true
+
+			statuses, err := self.search(ctx, "to:"+author, sinceId)
+			if err != nil {
+				return err
+			}
+			found = append(found, statuses...)
+		}
+
+		if mentions {
+			mentions = false
+			statuses, err := self.api.GetMentions(ctx,
+				twitter.TimelineOptions{Count: 200, SinceId: sinceId})
+			if err != nil {
+				return err
+			}
+			found = append(found, statuses...)
+		}
+
+		if !self.attach(tree, found) {
+			return nil
+		}
+	}
+}
+
+// Adds the statuses that reply to a node of the tree, including replies
+// to other statuses in found. Returns whether any were added.
+func (self *Builder) attach(tree *ConversationTree, found []twitter.Status) bool {
+	added := false
+
+	for progress := true; progress; {
+		progress = false
+		for _, status := range found {
+			self.remember(status)
+			if tree.Node(status.GetId()) != nil {
+				continue
+			}
+			parent := tree.Node(status.GetInReplyToStatusId())
+			if parent == nil {
+				continue
+			}
+
+			node := tree.node(status.GetId())
+			node.Status = status
+			tree.link(parent, node)
+			progress, added = true, true
+		}
+	}
+
+	return added
+}
+
+// Reads up to SearchPages pages of results newer than sinceId
+func (self *Builder) search(ctx context.Context, query string,
+	sinceId int64) ([]twitter.Status, error) {
+	var results []twitter.Status
+	opts := twitter.TimelineOptions{Count: kSearchPage, SinceId: sinceId}
+
+	for page := 0; page < self.SearchPages; page++ {
+		statuses, err := self.api.SearchStatuses(ctx, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) == 0 {
+			break
+		}
+
+		results = append(results, statuses...)
+		for _, status := range statuses {
+			if opts.MaxId == 0 || status.GetId() <= opts.MaxId {
+				opts.MaxId = status.GetId() - 1
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// Returns a status from the cache or the API. Deleted and protected
+// statuses give errMissing, and are remembered as such too.
+func (self *Builder) fetch(ctx context.Context, id int64) (twitter.Status, error) {
+	self.lock.Lock()
+	status, cached := self.statuses[id]
+	missing := self.missing[id]
+	self.lock.Unlock()
+
+	if missing {
+		return nil, errMissing
+	}
+	if cached {
+		return status, nil
+	}
+
+	status, err := self.api.ShowStatus(ctx, id)
+	if isUnavailable(err) {
+		self.lock.Lock()
+		self.missing[id] = true
+		self.lock.Unlock()
+		return nil, errMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	self.remember(status)
+	return status, nil
+}
+
+func (self *Builder) remember(status twitter.Status) {
+	self.lock.Lock()
+	self.statuses[status.GetId()] = status
+	self.lock.Unlock()
+}
+
+// Whether err says the status is gone or hidden from us, as opposed to a
+// failed request
+func isUnavailable(err error) bool {
+	var twitterErr *twitter.TwitterError
+	if errors.Is(err, twitter.ErrNotFound) {
+		return true
+	}
+	return errors.As(err, &twitterErr) && twitterErr.GetStatusCode() == 403
+}
@@ -0,0 +1,138 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package thread
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"twitter"
+)
+
+type tFakeSource struct {
+	statuses map[int64]twitter.Status
+	shown    map[int64]int
+	queries  []string
+}
+
+func newFakeSource(t *testing.T, lines ...string) *tFakeSource {
+	source := &tFakeSource{
+		statuses: make(map[int64]twitter.Status),
+		shown:    make(map[int64]int),
+	}
+	for _, line := range lines {
+		var id, parent int64
+		var author string
+		fmt.Sscan(line, &id, &parent, &author)
+		status, err := twitter.ParseStatus([]byte(fmt.Sprintf(
+			`{"id":%d,"in_reply_to_status_id":%d,"user":{"screen_name":%q}}`,
+			id, parent, author)))
+		if err != nil {
+			t.Fatalf("ParseStatus: %s", err)
+		}
+		source.statuses[id] = status
+	}
+	return source
+}
+
+func (self *tFakeSource) ShowStatus(ctx context.Context, id int64) (twitter.Status, error) {
+	self.shown[id]++
+	if status, ok := self.statuses[id]; ok {
+		return status, nil
+	}
+	return nil, twitter.ErrNotFound
+}
+
+// Answers to:name with the statuses replying to name's statuses
+func (self *tFakeSource) SearchStatuses(ctx context.Context, query string,
+	opts twitter.TimelineOptions) ([]twitter.Status, error) {
+	var results []twitter.Status
+	self.queries = append(self.queries, query)
+
+	for _, status := range self.statuses {
+		parent, ok := self.statuses[status.GetInReplyToStatusId()]
+		if ok && "to:"+parent.GetUser().GetScreenName() == query &&
+			status.GetId() > opts.SinceId && (opts.MaxId == 0 || status.GetId() <= opts.MaxId) {
+			results = append(results, status)
+		}
+	}
+	return results, nil
+}
+
+func (self *tFakeSource) GetMentions(ctx context.Context,
+	opts twitter.TimelineOptions) ([]twitter.Status, error) {
+	return nil, nil
+}
+
+func treeString(tree *ConversationTree) string {
+	var lines []string
+	tree.Walk(func(node *Node) {
+		mark := ""
+		if node.Missing {
+			mark = "?"
+		}
+		lines = append(lines, fmt.Sprintf("%s%d%s", strings.Repeat(" ", node.Depth), node.Id, mark))
+	})
+	return strings.Join(lines, ",")
+}
+
+func TestBuildTree(t *testing.T) {
+	source := newFakeSource(t,
+		"1 0 alice",
+		"2 1 bob",
+		"3 2 alice",
+		"4 1 carol",
+		"5 3 dave",
+		"6 4 alice",
+		"7 100 bob")
+	builder := NewBuilder(source)
+
+	tree, err := builder.BuildFromId(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("BuildFromId: %s", err)
+	}
+
+	if got := treeString(tree); got != "1, 2,  3,   5, 4,  6" {
+		t.Errorf("got tree %q", got)
+	}
+	if tree.Focus.Id != 3 || tree.Focus.Depth != 2 || tree.Len() != 6 {
+		t.Errorf("got focus %d at depth %d, %d nodes", tree.Focus.Id, tree.Focus.Depth, tree.Len())
+	}
+
+	// Everything is cached now
+	if _, err = builder.BuildFromId(context.Background(), 6); err != nil {
+		t.Fatalf("BuildFromId: %s", err)
+	}
+	for id, n := range source.shown {
+		if n > 1 {
+			t.Errorf("status %d fetched %d times", id, n)
+		}
+	}
+}
+
+func TestBuildMarksMissingRoot(t *testing.T) {
+	source := newFakeSource(t, "7 100 bob", "8 7 alice")
+
+	tree, err := NewBuilder(source).BuildFromId(context.Background(), 8)
+	if err != nil {
+		t.Fatalf("BuildFromId: %s", err)
+	}
+	if got := treeString(tree); got != "100?, 7,  8" {
+		t.Errorf("got tree %q", got)
+	}
+}
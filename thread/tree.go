@@ -0,0 +1,116 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package thread
+
+import (
+	"sort"
+
+	"twitter"
+)
+
+// A status in a conversation
+type Node struct {
+	Id int64
+
+	// nil if the status couldn't be fetched, see Missing
+	Status twitter.Status
+
+	// Set for statuses that are known to exist because something replies
+	// to them, but were deleted, protected or otherwise unavailable
+	Missing bool
+
+	// nil for the root of the tree
+	Parent *Node
+
+	// Direct replies, oldest first
+	Replies []*Node
+
+	// 0 for the root, 1 for its replies and so on
+	Depth int
+}
+
+// A conversation as a tree of replies. The root is the status the
+// conversation started with, or the oldest reachable one if the chain of
+// replies is broken by a missing status.
+type ConversationTree struct {
+	Root *Node
+
+	// The status the tree was built around
+	Focus *Node
+
+	nodes map[int64]*Node
+}
+
+func newTree() *ConversationTree {
+	return &ConversationTree{nodes: make(map[int64]*Node)}
+}
+
+// Returns the node of a status, or nil if it isn't part of the tree
+func (self *ConversationTree) Node(id int64) *Node { return self.nodes[id] }
+
+// The number of nodes, missing ones included
+func (self *ConversationTree) Len() int { return len(self.nodes) }
+
+// Calls fn for every node in reading order: depth first, replies oldest
+// first
+func (self *ConversationTree) Walk(fn func(node *Node)) {
+	if self.Root != nil {
+		walk(self.Root, fn)
+	}
+}
+
+func walk(node *Node, fn func(node *Node)) {
+	fn(node)
+	for _, reply := range node.Replies {
+		walk(reply, fn)
+	}
+}
+
+// Returns the nodes in the order Walk visits them
+func (self *ConversationTree) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(self.nodes))
+	self.Walk(func(node *Node) { nodes = append(nodes, node) })
+	return nodes
+}
+
+// Returns the node for id, adding a detached one if needed
+func (self *ConversationTree) node(id int64) *Node {
+	node, ok := self.nodes[id]
+	if !ok {
+		node = &Node{Id: id}
+		self.nodes[id] = node
+	}
+	return node
+}
+
+// Links child below parent, keeping the replies sorted. Status ids grow
+// over time so sorting by id sorts by age.
+func (self *ConversationTree) link(parent, child *Node) {
+	child.Parent = parent
+	parent.Replies = append(parent.Replies, child)
+	sort.Slice(parent.Replies, func(i, j int) bool {
+		return parent.Replies[i].Id < parent.Replies[j].Id
+	})
+}
+
+// Recomputes the depths from the root down
+func (self *ConversationTree) setDepths() {
+	self.Walk(func(node *Node) {
+		if node.Parent != nil {
+			node.Depth = node.Parent.Depth + 1
+		}
+	})
+}
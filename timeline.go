@@ -0,0 +1,117 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Paging options of the timeline calls. Zero values are left out.
+type TimelineOptions struct {
+	// The number of statuses to fetch
+	Count int
+
+	// Only return statuses newer than this id
+	SinceId int64
+
+	// Only return statuses with this id or older
+	MaxId int64
+}
+
+// Adds the options to params
+func (self *TimelineOptions) addParams(params url.Values) {
+	if self.Count > 0 {
+		params.Set("count", strconv.Itoa(self.Count))
+	}
+	if self.SinceId > 0 {
+		params.Set("since_id", strconv.FormatInt(self.SinceId, 10))
+	}
+	if self.MaxId > 0 {
+		params.Set("max_id", strconv.FormatInt(self.MaxId, 10))
+	}
+}
+
+// Fetches a timeline of statuses from one of the REST endpoints
+func (self *Api) getTimeline(ctx context.Context, url_ string, params url.Values,
+	opts TimelineOptions) ([]Status, error) {
+	var list []tTwitterStatus
+
+	opts.addParams(params)
+	if err := self.callJson(ctx, "GET", url_, params, &list); err != nil {
+		return nil, err
+	}
+
+	timeline := make([]Status, len(list))
+	for i := range list {
+		timeline[i] = self.internStatus(&list[i])
+	}
+	return timeline, nil
+}
+
+// Like GetStatus, but waits for the status and returns the error instead
+// of sending it to the error channel. Both share getStatus, and with it
+// one request when called at the same time for the same id.
+func (self *Api) ShowStatus(ctx context.Context, id int64) (Status, error) {
+	status, err := self.getStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (self *Api) getStatus(ctx context.Context, id int64) (*tTwitterStatus, error) {
+	status := newEmptyTwitterStatus()
+
+	data, err := self.getJson(ctx, fmt.Sprintf(_QUERY_GETSTATUS, id))
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(data), status); err != nil {
+		return nil, err
+	}
+	return self.internStatus(status), nil
+}
+
+// Like GetReplies, but takes paging options and returns the error instead
+// of sending it to the error channel. Both read statuses/mentions.
+func (self *Api) GetMentions(ctx context.Context, opts TimelineOptions) ([]Status, error) {
+	return self.getTimeline(ctx, _QUERY_REPLIES, url.Values{}, opts)
+}
+
+// Searches the statuses of the last week, newest first. Unlike Search
+// it returns full statuses and pages by status id.
+func (self *Api) SearchStatuses(ctx context.Context, query string,
+	opts TimelineOptions) ([]Status, error) {
+	var result struct {
+		Statuses []tTwitterStatus
+	}
+	params := url.Values{"q": {query}, "result_type": {"recent"}}
+
+	opts.addParams(params)
+	if err := self.callJson(ctx, "GET", _QUERY_SEARCHSTATUSES, params, &result); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(result.Statuses))
+	for i := range result.Statuses {
+		statuses[i] = self.internStatus(&result.Statuses[i])
+	}
+	return statuses, nil
+}
@@ -0,0 +1,206 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// A trending topic
+type Trend interface {
+	GetName() string
+	GetUrl() string
+	// The search query for the topic, URL encoded
+	GetQuery() string
+	// The number of statuses about the topic in the last 24 hours, 0 if
+	// Twitter doesn't say
+	GetTweetVolume() int
+	GetPromotedContent() bool
+	// When the trends were computed
+	GetAsOf() time.Time
+	// When the trends first appeared
+	GetCreatedAt() time.Time
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+// A place Twitter has trends for, identified by its Yahoo! Where On Earth
+// id
+type Location interface {
+	GetName() string
+	GetWoeid() int64
+	GetParentId() int64
+	GetCountry() string
+	GetCountryCode() string
+	GetUrl() string
+	// Town, Country, Supername, ...
+	GetPlaceType() string
+	GetPlaceTypeCode() int
+	MarshalJSON() ([]byte, error)
+	RawFields
+}
+
+type tTwitterTrend struct {
+	Name             string `json:"name"`
+	Url              string `json:"url"`
+	Query            string `json:"query"`
+	Tweet_volume     int    `json:"tweet_volume"`
+	Promoted_content bool   `json:"promoted_content"`
+	// Copied from the list the trend came in
+	As_of      string `json:"as_of,omitempty"`
+	Created_at string `json:"created_at,omitempty"`
+	tJsonFields
+}
+
+// trends/place wraps the trends of a place together with their timestamps
+type tTwitterTrendList struct {
+	Trends     []tTwitterTrend
+	As_of      string
+	Created_at string
+}
+
+type tTwitterLocation struct {
+	Name        string `json:"name"`
+	Woeid       int64  `json:"woeid"`
+	Parentid    int64  `json:"parentid"`
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+	Url         string `json:"url"`
+	PlaceType   struct {
+		Code int    `json:"code"`
+		Name string `json:"name"`
+	} `json:"placeType"`
+	tJsonFields
+}
+
+func (self *tTwitterTrend) UnmarshalJSON(data []byte) error {
+	type plain tTwitterTrend
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterTrend) MarshalJSON() ([]byte, error) {
+	type plain tTwitterTrend
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterTrend) GetName() string { return self.Name }
+
+func (self *tTwitterTrend) GetUrl() string { return self.Url }
+
+func (self *tTwitterTrend) GetQuery() string { return self.Query }
+
+func (self *tTwitterTrend) GetTweetVolume() int { return self.Tweet_volume }
+
+func (self *tTwitterTrend) GetPromotedContent() bool { return self.Promoted_content }
+
+func (self *tTwitterTrend) GetAsOf() time.Time {
+	asOf, _ := time.Parse(time.RFC3339, self.As_of)
+	return asOf
+}
+
+func (self *tTwitterTrend) GetCreatedAt() time.Time {
+	createdAt, _ := time.Parse(time.RFC3339, self.Created_at)
+	return createdAt
+}
+
+func (self *tTwitterLocation) UnmarshalJSON(data []byte) error {
+	type plain tTwitterLocation
+	return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterLocation) MarshalJSON() ([]byte, error) {
+	type plain tTwitterLocation
+	return self.marshalFields((*plain)(self))
+}
+
+func (self *tTwitterLocation) GetName() string { return self.Name }
+
+func (self *tTwitterLocation) GetWoeid() int64 { return self.Woeid }
+
+func (self *tTwitterLocation) GetParentId() int64 { return self.Parentid }
+
+func (self *tTwitterLocation) GetCountry() string { return self.Country }
+
+func (self *tTwitterLocation) GetCountryCode() string { return self.CountryCode }
+
+func (self *tTwitterLocation) GetUrl() string { return self.Url }
+
+func (self *tTwitterLocation) GetPlaceType() string { return self.PlaceType.Name }
+
+func (self *tTwitterLocation) GetPlaceTypeCode() int { return self.PlaceType.Code }
+
+// Returns the top 50 trending topics of a place
+//
+// woeid:
+//  The Yahoo! Where On Earth id of the place, 1 for worldwide trends. See
+//  AvailableTrendLocations.
+// exclude:
+//  Set to "hashtags" to leave out hashtags, or an empty string to keep them
+func (self *Api) TrendsForPlace(ctx context.Context, woeid int64, exclude string) ([]Trend, error) {
+	var lists []tTwitterTrendList
+	params := url.Values{"id": {strconv.FormatInt(woeid, 10)}}
+
+	if exclude != "" {
+		params.Set("exclude", exclude)
+	}
+
+	if err := self.callJson(ctx, "GET", _QUERY_TRENDSPLACE, params, &lists); err != nil {
+		return nil, err
+	}
+
+	var trends []Trend
+	for i := range lists {
+		for j := range lists[i].Trends {
+			trend := &lists[i].Trends[j]
+			trend.As_of = lists[i].As_of
+			trend.Created_at = lists[i].Created_at
+			trends = append(trends, trend)
+		}
+	}
+
+	return trends, nil
+}
+
+// Returns every location Twitter has trends for
+func (self *Api) AvailableTrendLocations(ctx context.Context) ([]Location, error) {
+	return self.getLocations(ctx, _QUERY_TRENDSAVAILABLE, nil)
+}
+
+// Returns the trend locations closest to a point
+func (self *Api) ClosestTrendLocations(ctx context.Context, lat, long float64) ([]Location, error) {
+	params := url.Values{
+		"lat":  {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"long": {strconv.FormatFloat(long, 'f', -1, 64)},
+	}
+	return self.getLocations(ctx, _QUERY_TRENDSCLOSEST, params)
+}
+
+func (self *Api) getLocations(ctx context.Context, url_ string, params url.Values) ([]Location, error) {
+	var list []tTwitterLocation
+
+	if err := self.callJson(ctx, "GET", url_, params, &list); err != nil {
+		return nil, err
+	}
+
+	locations := make([]Location, len(list))
+	for i := range list {
+		locations[i] = &list[i]
+	}
+	return locations, nil
+}
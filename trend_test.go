@@ -0,0 +1,57 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTrendsForPlace(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body: `[{"trends":[{"name":"#GoLang","url":"http://twitter.com/search?q=%23GoLang",` +
+			`"promoted_content":null,"query":"%23GoLang","tweet_volume":31227},` +
+			`{"name":"Gophers","query":"Gophers","tweet_volume":null}],` +
+			`"as_of":"2026-10-19T16:18:18Z","created_at":"2026-10-19T16:13:21Z",` +
+			`"locations":[{"name":"Worldwide","woeid":1}]}]`,
+		check: func(req *http.Request) {
+			if req.URL.Query().Get("id") != "1" || req.URL.Query().Get("exclude") != "hashtags" {
+				t.Errorf("got query %s", req.URL.RawQuery)
+			}
+		},
+	})
+
+	trends, err := api.TrendsForPlace(context.Background(), 1, "hashtags")
+	if err != nil {
+		t.Fatalf("TrendsForPlace: %s", err)
+	}
+	if len(trends) != 2 {
+		t.Fatalf("got %d trends expected 2", len(trends))
+	}
+
+	asOf := time.Date(2026, 10, 19, 16, 18, 18, 0, time.UTC)
+	if trends[0].GetTweetVolume() != 31227 || trends[1].GetTweetVolume() != 0 {
+		t.Errorf("GetTweetVolume: got %d and %d", trends[0].GetTweetVolume(),
+			trends[1].GetTweetVolume())
+	}
+	if !trends[1].GetAsOf().Equal(asOf) || trends[1].GetPromotedContent() {
+		t.Errorf("got as of %s promoted %v", trends[1].GetAsOf(),
+			trends[1].GetPromotedContent())
+	}
+}
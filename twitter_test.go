@@ -15,19 +15,48 @@
 //
 package twitter
 
-import "testing"
-
-import "fmt"
+import (
+	"fmt"
+	"testing"
+	"twitter/twittertest"
+)
 
 // dont change this
 const kId = 5641609144
 
-func TestValidStatus(t *testing.T) {
+// Returns an Api talking to a fake server seeded with jb55, kId, a few
+// followers and friends and some #ff statuses
+func newValidApi(t *testing.T) (*Api, *twittertest.Server) {
+	server := twittertest.NewServer()
+	t.Cleanup(server.Close)
+
+	server.AddUser(twittertest.User{Id: 9918032, ScreenName: "jb55",
+		Name: "Bill Casarin", Location: "Vancouver",
+		Followers: []int64{1, 2}, Friends: []int64{2, 3}, FavoritesCount: 3})
+	server.AddUser(twittertest.User{Id: 1, ScreenName: "gopher", Name: "Gopher",
+		Friends: []int64{9918032}})
+	server.AddUser(twittertest.User{Id: 2, ScreenName: "rob", Name: "Rob",
+		Followers: []int64{9918032}, Friends: []int64{9918032}})
+	server.AddUser(twittertest.User{Id: 3, ScreenName: "ken", Name: "Ken",
+		Followers: []int64{9918032}})
+
+	server.AddStatus(twittertest.Status{Id: kId, UserId: 9918032, Text: "hello from go"})
+	server.AddStatus(twittertest.Status{UserId: 1, Text: "#ff @jb55 @rob"})
+	server.AddStatus(twittertest.Status{UserId: 2, Text: "#ff @ken"})
+	server.AddStatus(twittertest.Status{UserId: 3, Text: "reading the go spec",
+		InReplyToStatusId: kId})
+
 	api := NewApi()
+	api.SetHTTPClient(server.Client())
+	return api, server
+}
+
+func TestValidStatus(t *testing.T) {
+	api, _ := newValidApi(t)
 	errors := api.GetErrorChannel()
 
 	fmt.Printf("<-api.GetStatus() ...\n")
-	status := (<-api.GetUser("jb55")).GetStatus()
+	status := (<-api.GetUser(ByScreenName("jb55"))).GetStatus()
 
 	verifyValidStatus(status, t)
 	verifyValidUser(status.GetUser(), t)
@@ -35,11 +64,11 @@ func TestValidStatus(t *testing.T) {
 }
 
 func TestValidUser(t *testing.T) {
-	api := NewApi()
+	api, _ := newValidApi(t)
 	errors := api.GetErrorChannel()
 
-	fmt.Printf("<-api.GetUserById() ...\n")
-	user := <-api.GetUserById(9918032)
+	fmt.Printf("<-api.GetUser(ByID()) ...\n")
+	user := <-api.GetUser(ByID(9918032))
 
 	verifyValidUser(user, t)
 	verifyValidStatus(user.GetStatus(), t)
@@ -47,10 +76,10 @@ func TestValidUser(t *testing.T) {
 }
 
 func TestValidFollowerList(t *testing.T) {
-	api := NewApi()
+	api, _ := newValidApi(t)
 	errors := api.GetErrorChannel()
 	fmt.Printf("<-api.GetFollowers() ...\n")
-	users := <-api.GetFollowers("jb55", 0)
+	users := <-api.GetFollowers(ByScreenName("jb55"), 0)
 	length := len(users)
 
 	if length <= 1 {
@@ -68,10 +97,10 @@ func TestValidFollowerList(t *testing.T) {
 }
 
 func TestValidFriendsList(t *testing.T) {
-	api := NewApi()
+	api, _ := newValidApi(t)
 	errors := api.GetErrorChannel()
 	fmt.Printf("<-api.GetFriends() ...\n")
-	users := <-api.GetFriends("jb55", 0)
+	users := <-api.GetFriends(ByScreenName("jb55"), 0)
 	length := len(users)
 
 	if length <= 1 {
@@ -89,7 +118,7 @@ func TestValidFriendsList(t *testing.T) {
 }
 
 func TestValidSearchResults(t *testing.T) {
-	api := NewApi()
+	api, _ := newValidApi(t)
 	errors := api.GetErrorChannel()
 	fmt.Printf("<-api.SearchSimple() ...\n")
 	results := <-api.SearchSimple("#ff")
@@ -107,7 +136,7 @@ func TestValidSearchResults(t *testing.T) {
 }
 
 func TestValidPublicTimeLine(t *testing.T) {
-	api := NewApi()
+	api, _ := newValidApi(t)
 	errors := api.GetErrorChannel()
 	fmt.Printf("<-api.GetPublicTimeline() ...\n")
 	statuses := <-api.GetPublicTimeline()
@@ -129,6 +158,28 @@ func TestValidPublicTimeLine(t *testing.T) {
 	getAllApiErrors(errors, t)
 }
 
+func TestServerErrorReported(t *testing.T) {
+	api, server := newValidApi(t)
+	server.Fail("users/show", twittertest.Failure{Status: 503, Code: 130,
+		Message: "Over capacity", Times: 1})
+
+	<-api.GetUser(ByScreenName("jb55"))
+	if !api.HasErrors() {
+		t.Errorf("HasErrors: got false expected true")
+	}
+
+	user := <-api.GetUser(ByScreenName("jb55"))
+	verifyValidUser(user, t)
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("len(Requests()): got %d expected 2", len(requests))
+	}
+	if requests[1].Endpoint != "users/show" || requests[1].Form.Get("screen_name") != "jb55" {
+		t.Errorf("Requests()[1]: got %s %v", requests[1].Endpoint, requests[1].Form)
+	}
+}
+
 // Authfile: .twitterauth
 // Format: single line, two words
 //    username password
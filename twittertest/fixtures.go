@@ -0,0 +1,132 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twittertest
+
+import (
+	"strconv"
+	"time"
+)
+
+// A user known to the Server
+type User struct {
+	Id          int64
+	ScreenName  string
+	Name        string
+	Location    string
+	Description string
+	Protected   bool
+
+	// The ids of the users following this one and the users this one
+	// follows. They make up the follower and friend lists and counts.
+	Followers []int64
+	Friends   []int64
+
+	FavoritesCount int
+}
+
+// A status known to the Server
+type Status struct {
+	// Assigned by AddStatus if 0
+	Id     int64
+	UserId int64
+	Text   string
+
+	// time.Now() if zero
+	CreatedAt time.Time
+
+	InReplyToStatusId int64
+}
+
+// Twitter's created_at format
+const kTimeFormat = "Mon Jan 02 15:04:05 -0700 2006"
+
+type object map[string]interface{}
+
+func (self *Server) userJson(user *User, withStatus bool) object {
+	statusesCount := 0
+	var latest *Status
+	for _, status := range self.statuses {
+		if status.UserId == user.Id {
+			statusesCount++
+			if latest == nil || status.Id > latest.Id {
+				latest = status
+			}
+		}
+	}
+
+	json := object{
+		"id":               user.Id,
+		"id_str":           strconv.FormatInt(user.Id, 10),
+		"screen_name":      user.ScreenName,
+		"name":             user.Name,
+		"location":         user.Location,
+		"description":      user.Description,
+		"protected":        user.Protected,
+		"followers_count":  len(user.Followers),
+		"friends_count":    len(user.Friends),
+		"favourites_count": user.FavoritesCount,
+		"statuses_count":   statusesCount,
+	}
+	if withStatus && latest != nil {
+		json["status"] = self.statusJson(latest, false)
+	}
+	return json
+}
+
+func (self *Server) statusJson(status *Status, withUser bool) object {
+	json := object{
+		"id":                      status.Id,
+		"id_str":                  strconv.FormatInt(status.Id, 10),
+		"text":                    status.Text,
+		"created_at":              status.CreatedAt.UTC().Format(kTimeFormat),
+		"favorited":               false,
+		"in_reply_to_status_id":   status.InReplyToStatusId,
+		"in_reply_to_user_id":     0,
+		"in_reply_to_screen_name": "",
+	}
+
+	if parent, ok := self.statuses[status.InReplyToStatusId]; ok {
+		json["in_reply_to_user_id"] = parent.UserId
+		if author, ok := self.users[parent.UserId]; ok {
+			json["in_reply_to_screen_name"] = author.ScreenName
+		}
+	}
+	if author, ok := self.users[status.UserId]; ok && withUser {
+		json["user"] = self.userJson(author, false)
+	}
+	return json
+}
+
+// The shape of the results of the old search API
+func (self *Server) searchResultJson(status *Status) object {
+	json := object{
+		"id":                status.Id,
+		"text":              status.Text,
+		"created_at":        status.CreatedAt.UTC().Format(time.RFC1123Z),
+		"from_user_id":      status.UserId,
+		"to_user_id":        0,
+		"iso_language_code": "en",
+		"source":            "web",
+		"geo":               nil,
+	}
+	if author, ok := self.users[status.UserId]; ok {
+		json["from_user"] = author.ScreenName
+	}
+	if parent, ok := self.statuses[status.InReplyToStatusId]; ok {
+		json["to_user_id"] = parent.UserId
+	}
+	return json
+}
@@ -0,0 +1,362 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twittertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Whether a Recorder talks to the network or plays back its cassette
+type Mode int
+
+const (
+	// Answers requests from the cassette, never touching the network
+	ModeReplay Mode = iota
+
+	// Sends requests on and saves them with their responses on Stop
+	ModeRecord
+)
+
+// Setting this environment variable makes ModeFromEnv return ModeRecord
+const RecordEnv = "TWITTERTEST_RECORD"
+
+const kRedacted = "REDACTED"
+
+// Headers that never make it into a cassette
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Access-Token"}
+
+// Query and form parameters that are scrubbed and ignored when matching,
+// they change with every request or hold secrets
+var scrubbedParams = map[string]bool{
+	"oauth_signature":    true,
+	"oauth_nonce":        true,
+	"oauth_timestamp":    true,
+	"oauth_token":        true,
+	"oauth_token_secret": true,
+	"oauth_consumer_key": true,
+	"oauth_verifier":     true,
+	"x_auth_password":    true,
+}
+
+// A request as saved in a cassette
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Url    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// A response as saved in a cassette
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// The interactions a Recorder saved or replays, stored as indented JSON
+// so changes to a cassette read well in a diff
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// An http.RoundTripper that records real request/response pairs into a
+// cassette file, conventionally under testdata, and replays them later:
+//
+//    recorder, err := twittertest.NewRecorder("testdata/user.json",
+//        twittertest.ModeFromEnv())
+//    defer recorder.Stop()
+//    api.SetHTTPClient(recorder.Client())
+//
+// Credentials are scrubbed before anything is written. On replay a
+// request matches a recorded one with the same method, URL path, query
+// and form parameters; parameter order and OAuth nonces, timestamps and
+// signatures don't matter. Matching interactions are replayed in the
+// order they were recorded, the last one repeating once all were used.
+type Recorder struct {
+	// Sends the requests while recording, http.DefaultTransport if nil
+	Transport http.RoundTripper
+
+	path string
+	mode Mode
+
+	lock     sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Returns ModeRecord if RecordEnv is set, ModeReplay otherwise
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Creates a recorder for the cassette at path. Replaying needs the
+// cassette to exist, recording replaces it on Stop.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	self := &Recorder{path: path, mode: mode}
+	if mode == ModeRecord {
+		return self, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &self.cassette); err != nil {
+		return nil, fmt.Errorf("twittertest: cassette %s: %s", path, err)
+	}
+	self.used = make([]bool, len(self.cassette.Interactions))
+	return self, nil
+}
+
+// Returns a client sending its requests through the recorder. Pass it to
+// Api.SetHTTPClient.
+func (self *Recorder) Client() *http.Client { return &http.Client{Transport: self} }
+
+func (self *Recorder) Mode() Mode { return self.mode }
+
+// Returns the interactions recorded or loaded so far
+func (self *Recorder) Interactions() []*Interaction {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	return append([]*Interaction(nil), self.cassette.Interactions...)
+}
+
+func (self *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if self.mode == ModeReplay {
+		return self.replay(req, body)
+	}
+	return self.record(req, body)
+}
+
+// Saves the cassette when recording, creating its directory if needed.
+// Does nothing when replaying.
+func (self *Recorder) Stop() error {
+	if self.mode != ModeRecord {
+		return nil
+	}
+
+	self.lock.Lock()
+	data, err := json.MarshalIndent(&self.cassette, "", "  ")
+	self.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(self.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(self.path, append(data, '\n'), 0644)
+}
+
+func (self *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := self.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	r, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	interaction := &Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Url:    scrubUrl(req.URL),
+			Header: scrubHeader(req.Header),
+			Body:   scrubBody(req.Header, body),
+		},
+		Response: RecordedResponse{
+			Status: r.StatusCode,
+			Header: scrubHeader(r.Header),
+			Body:   string(data),
+		},
+	}
+
+	self.lock.Lock()
+	self.cassette.Interactions = append(self.cassette.Interactions, interaction)
+	self.lock.Unlock()
+
+	return r, nil
+}
+
+func (self *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	last := -1
+	for i, interaction := range self.cassette.Interactions {
+		if !matches(req, body, &interaction.Request) {
+			continue
+		}
+		if !self.used[i] {
+			last = i
+			break
+		}
+		last = i
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("twittertest: %s has no interaction for %s %s",
+			self.path, req.Method, req.URL)
+	}
+	self.used[last] = true
+
+	recorded := self.cassette.Interactions[last].Response
+	header := http.Header{}
+	for key, values := range recorded.Header {
+		header[key] = append([]string(nil), values...)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Reads the body of req and puts it back so it can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func matches(req *http.Request, body []byte, recorded *RecordedRequest) bool {
+	if req.Method != recorded.Method {
+		return false
+	}
+
+	u, err := url.Parse(recorded.Url)
+	if err != nil || u.Host != req.URL.Host || u.Path != req.URL.Path ||
+		!sameParams(u.Query(), req.URL.Query()) {
+		return false
+	}
+
+	if isForm(req.Header) {
+		recordedForm, _ := url.ParseQuery(recorded.Body)
+		form, _ := url.ParseQuery(string(body))
+		return sameParams(recordedForm, form)
+	}
+	return recorded.Body == scrubBody(req.Header, body)
+}
+
+// Compares parameters, ignoring order and the scrubbed ones
+func sameParams(a, b url.Values) bool {
+	for _, values := range []url.Values{a, b} {
+		for key := range values {
+			if scrubbedParams[key] {
+				continue
+			}
+			if strings.Join(a[key], "\x00") != strings.Join(b[key], "\x00") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isForm(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "application/x-www-form-urlencoded")
+}
+
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := http.Header{}
+	for key, values := range header {
+		scrubbed[key] = append([]string(nil), values...)
+	}
+	for _, key := range scrubbedHeaders {
+		scrubbed.Del(key)
+	}
+	if len(scrubbed) == 0 {
+		return nil
+	}
+	return scrubbed
+}
+
+func scrubParams(params url.Values) url.Values {
+	scrubbed := url.Values{}
+	for key, values := range params {
+		if scrubbedParams[key] {
+			values = []string{kRedacted}
+		}
+		scrubbed[key] = values
+	}
+	return scrubbed
+}
+
+func scrubUrl(u *url.URL) string {
+	scrubbed := *u
+	scrubbed.User = nil
+	if scrubbed.RawQuery != "" {
+		scrubbed.RawQuery = scrubParams(u.Query()).Encode()
+	}
+	return scrubbed.String()
+}
+
+func scrubBody(header http.Header, body []byte) string {
+	if !isForm(header) || len(body) == 0 {
+		return string(body)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return string(body)
+	}
+	return scrubParams(form).Encode()
+}
@@ -0,0 +1,110 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twittertest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordScrubsAndReplays(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddUser(User{Id: 1, ScreenName: "jb55", Name: "Bill"})
+
+	path := filepath.Join(t.TempDir(), "testdata", "users.json")
+	recorder, err := NewRecorder(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder: %s", err)
+	}
+	recorder.Transport = server.Client().Transport
+
+	req, _ := http.NewRequest("GET", "https://api.twitter.com/1.1/users/show.json?"+
+		"screen_name=jb55&oauth_nonce=1&oauth_signature=s3cret&oauth_token_secret=t0ken&"+
+		"oauth_verifier=v3rifier&x_auth_password=passw0rd", nil)
+	req.SetBasicAuth("jb55", "hunter2")
+	r, err := recorder.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Do: %s", err)
+	}
+	live, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+
+	if err = recorder.Stop(); err != nil {
+		t.Fatalf("Stop: %s", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{"s3cret", "t0ken", "v3rifier", "passw0rd", "hunter2",
+		"Authorization"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+
+	replayer, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder(replay): %s", err)
+	}
+	server.Close()
+
+	// Parameters in another order and with fresh OAuth values still match
+	r, err = replayer.Client().Get("https://api.twitter.com/1.1/users/show.json?" +
+		"oauth_signature=other&oauth_nonce=2&screen_name=jb55")
+	if err != nil {
+		t.Fatalf("replay: %s", err)
+	}
+	replayed, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if r.StatusCode != 200 || string(replayed) != string(live) {
+		t.Errorf("replay: got %d %s expected 200 %s", r.StatusCode, replayed, live)
+	}
+
+	if _, err = replayer.Client().Get("https://api.twitter.com/1.1/users/show.json?screen_name=rob"); err == nil {
+		t.Errorf("unmatched request: got no error")
+	}
+}
+
+func TestReplayInOrder(t *testing.T) {
+	recorder := &Recorder{path: "inline", cassette: Cassette{Interactions: []*Interaction{
+		{RecordedRequest{Method: "POST", Url: "http://twitter.com/a.json",
+			Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:   "status=hi"}, RecordedResponse{Status: 200, Body: "first"}},
+		{RecordedRequest{Method: "POST", Url: "http://twitter.com/a.json",
+			Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:   "status=hi"}, RecordedResponse{Status: 403, Body: "second"}},
+	}}}
+	recorder.used = make([]bool, 2)
+
+	for _, expected := range []string{"first", "second", "second"} {
+		r, err := recorder.Client().PostForm("http://twitter.com/a.json", url.Values{"status": {"hi"}})
+		if err != nil {
+			t.Fatalf("PostForm: %s", err)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if string(body) != expected {
+			t.Errorf("replay: got %s expected %s", body, expected)
+		}
+	}
+
+	if _, err := recorder.Client().PostForm("http://twitter.com/a.json", url.Values{"status": {"bye"}}); err == nil {
+		t.Errorf("different form: got no error")
+	}
+}
@@ -0,0 +1,679 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Package twittertest runs a fake Twitter API in-process, for testing code
+// that uses the twitter package without a network. Seed it with users and
+// statuses, point an Api at it and inspect the requests it got:
+//
+//    server := twittertest.NewServer()
+//    defer server.Close()
+//    server.AddUser(twittertest.User{Id: 1, ScreenName: "jb55"})
+//    server.AddStatus(twittertest.Status{UserId: 1, Text: "hello"})
+//
+//    api := twitter.NewApi()
+//    api.SetHTTPClient(server.Client())
+//
+// The server answers the legacy and the 1.1 URLs of the status, timeline,
+// user, follower, search, rate limit and update endpoints the Api uses.
+// Failures, latency and rate limiting can be switched on per test.
+package twittertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	kDefaultCount     = 20
+	kRateLimitWindow  = 15 * time.Minute
+	kMaxStatusLength  = 280
+	kFirstAssignedId  = 1000
+	kRateLimitedCode  = 88
+	kRateLimitedError = "Rate limit exceeded"
+)
+
+// An error response to send instead of the real answer, see Server.Fail
+type Failure struct {
+	// The HTTP status code
+	Status int
+
+	// The Twitter error code and message of the body
+	Code    int
+	Message string
+
+	// How many requests fail, all of them if 0
+	Times int
+}
+
+// A request the server received
+type Request struct {
+	Method string
+
+	// The path without version and extension, e.g. "users/show"
+	Endpoint string
+
+	Url *url.URL
+
+	// The query and form parameters
+	Form url.Values
+
+	// The basic auth user name, empty for unauthenticated requests
+	User string
+}
+
+// A fake Twitter API. Its methods may be called while requests are served.
+type Server struct {
+	// The base URL of the server
+	URL string
+
+	server *httptest.Server
+
+	lock      sync.Mutex
+	users     map[int64]*User
+	statuses  map[int64]*Status
+	nextId    int64
+	failures  map[string]*Failure
+	latency   time.Duration
+	rateLimit int
+	remaining int
+	reset     time.Time
+	requests  []Request
+}
+
+type tRequest struct {
+	*http.Request
+	id string
+}
+
+type tHandler func(self *Server, req *tRequest) (interface{}, *Failure)
+
+var routes = map[string]tHandler{
+	"statuses/show":              (*Server).showStatus,
+	"statuses/update":            (*Server).updateStatus,
+	"statuses/public_timeline":   (*Server).publicTimeline,
+	"statuses/user_timeline":     (*Server).userTimeline,
+	"statuses/home_timeline":     (*Server).homeTimeline,
+	"statuses/friends_timeline":  (*Server).homeTimeline,
+	"statuses/mentions_timeline": (*Server).mentions,
+	"statuses/mentions":          (*Server).mentions,
+	"statuses/followers":         (*Server).followers,
+	"statuses/friends":           (*Server).friends,
+	"followers/ids":              (*Server).followerIds,
+	"friends/ids":                (*Server).friendIds,
+	"users/show":                 (*Server).showUser,
+	"users/lookup":               (*Server).lookupUsers,
+	"search":                     (*Server).search,
+	"search/tweets":              (*Server).searchTweets,
+	"account/rate_limit_status":  (*Server).rateLimitStatus,
+	"account/verify_credentials": (*Server).verifyCredentials,
+}
+
+var (
+	errNoAuth      = &Failure{Status: 400, Code: 215, Message: "Bad Authentication data."}
+	errBadAuth     = &Failure{Status: 401, Code: 32, Message: "Could not authenticate you."}
+	errNoPage      = &Failure{Status: 404, Code: 34, Message: "Sorry, that page does not exist."}
+	errNoStatus    = &Failure{Status: 404, Code: 144, Message: "No status found with that ID."}
+	errNoUser      = &Failure{Status: 404, Code: 50, Message: "User not found."}
+	errTooLong     = &Failure{Status: 403, Code: 186, Message: "Tweet needs to be a bit shorter."}
+	errDuplicate   = &Failure{Status: 403, Code: 187, Message: "Status is a duplicate."}
+	errNotPost     = &Failure{Status: 405, Message: "POST required."}
+	errRateLimited = &Failure{Status: 429, Code: kRateLimitedCode, Message: kRateLimitedError}
+)
+
+// Starts a server without any users or statuses
+func NewServer() *Server {
+	self := &Server{
+		users:    make(map[int64]*User),
+		statuses: make(map[int64]*Status),
+		nextId:   kFirstAssignedId,
+		failures: make(map[string]*Failure),
+	}
+	self.server = httptest.NewServer(self)
+	self.URL = self.server.URL
+	return self
+}
+
+func (self *Server) Close() { self.server.Close() }
+
+// Returns a client sending every request to the server, whatever host the
+// URL names. Pass it to Api.SetHTTPClient.
+func (self *Server) Client() *http.Client {
+	target, _ := url.Parse(self.server.URL)
+	return &http.Client{Transport: &tRewriteTransport{target, self.server.Client().Transport}}
+}
+
+type tRewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (self *tRewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rewritten := req.Clone(req.Context())
+	rewritten.URL.Scheme = self.target.Scheme
+	rewritten.URL.Host = self.target.Host
+	rewritten.Host = req.URL.Host
+	return self.base.RoundTrip(rewritten)
+}
+
+// Adds or replaces a user
+func (self *Server) AddUser(user User) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.users[user.Id] = &user
+}
+
+// Adds or replaces a status and returns its id
+func (self *Server) AddStatus(status Status) int64 {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	return self.addStatus(status).Id
+}
+
+func (self *Server) addStatus(status Status) *Status {
+	if status.Id == 0 {
+		self.nextId++
+		status.Id = self.nextId
+	}
+	if status.CreatedAt.IsZero() {
+		status.CreatedAt = time.Now()
+	}
+	self.statuses[status.Id] = &status
+	return &status
+}
+
+// Returns a status by id, for checking what an update created
+func (self *Server) Status(id int64) (Status, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if status, ok := self.statuses[id]; ok {
+		return *status, true
+	}
+	return Status{}, false
+}
+
+// Makes requests to an endpoint, such as "users/show", fail. Replaces any
+// earlier failure of the endpoint.
+func (self *Server) Fail(endpoint string, failure Failure) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.failures[endpoint] = &failure
+}
+
+func (self *Server) ClearFailures() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.failures = make(map[string]*Failure)
+}
+
+// Delays every response by d
+func (self *Server) SetLatency(d time.Duration) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.latency = d
+}
+
+// Allows limit requests per 15 minute window, after which requests fail
+// with HTTP 429 and code 88. Starts a new window; 0 turns limiting off.
+func (self *Server) SetRateLimit(limit int) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.rateLimit = limit
+	self.remaining = limit
+	self.reset = time.Now().Add(kRateLimitWindow)
+}
+
+// Returns the requests received so far, oldest first
+func (self *Server) Requests() []Request {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	return append([]Request(nil), self.requests...)
+}
+
+func (self *Server) ClearRequests() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.requests = nil
+}
+
+// Splits a path into the endpoint and the id some legacy URLs end with
+func route(path string) (endpoint, id string) {
+	endpoint = strings.TrimSuffix(strings.Trim(path, "/"), ".json")
+	endpoint = strings.TrimPrefix(endpoint, "1.1/")
+
+	if strings.HasPrefix(endpoint, "statuses/show/") {
+		return "statuses/show", strings.TrimPrefix(endpoint, "statuses/show/")
+	}
+	return endpoint, ""
+}
+
+func (self *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	endpoint, id := route(r.URL.Path)
+	user, _, _ := r.BasicAuth()
+
+	self.lock.Lock()
+	self.requests = append(self.requests, Request{
+		Method:   r.Method,
+		Endpoint: endpoint,
+		Url:      r.URL,
+		Form:     r.Form,
+		User:     user,
+	})
+	latency := self.latency
+	self.lock.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if failure := self.failure(endpoint); failure != nil {
+		writeFailure(w, failure)
+		return
+	}
+	if endpoint != "account/rate_limit_status" && self.rateLimit > 0 {
+		self.setRateLimitHeaders(w)
+		if self.remaining == 0 {
+			writeFailure(w, errRateLimited)
+			return
+		}
+		self.remaining--
+	}
+
+	handler, ok := routes[endpoint]
+	if !ok {
+		writeFailure(w, errNoPage)
+		return
+	}
+
+	v, failure := handler(self, &tRequest{r, id})
+	if failure != nil {
+		writeFailure(w, failure)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Returns the failure set for endpoint and counts it down
+func (self *Server) failure(endpoint string) *Failure {
+	failure, ok := self.failures[endpoint]
+	if !ok {
+		return nil
+	}
+	if failure.Times > 0 {
+		if failure.Times--; failure.Times == 0 {
+			delete(self.failures, endpoint)
+		}
+	}
+	return failure
+}
+
+func (self *Server) setRateLimitHeaders(w http.ResponseWriter) {
+	remaining := self.remaining
+	if remaining > 0 {
+		remaining--
+	}
+	w.Header().Set("X-Rate-Limit-Limit", strconv.Itoa(self.rateLimit))
+	w.Header().Set("X-Rate-Limit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-Rate-Limit-Reset", strconv.FormatInt(self.reset.Unix(), 10))
+}
+
+func writeFailure(w http.ResponseWriter, failure *Failure) {
+	body := object{"errors": []object{{"code": failure.Code, "message": failure.Message}}}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(failure.Status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Returns the user the request is authenticated as. Passwords aren't
+// checked, the user name must be the screen name of a known user.
+func (self *Server) authUser(req *tRequest) (*User, *Failure) {
+	name, _, ok := req.BasicAuth()
+	if !ok {
+		return nil, errNoAuth
+	}
+	if user := self.userByName(name); user != nil {
+		return user, nil
+	}
+	return nil, errBadAuth
+}
+
+func (self *Server) userByName(name string) *User {
+	for _, user := range self.users {
+		if strings.EqualFold(user.ScreenName, name) {
+			return user
+		}
+	}
+	return nil
+}
+
+// Returns the user named by the user_id or screen_name parameter, or the
+// authenticated user if there is neither
+func (self *Server) targetUser(req *tRequest) (*User, *Failure) {
+	if name := req.Form.Get("screen_name"); name != "" {
+		if user := self.userByName(name); user != nil {
+			return user, nil
+		}
+		return nil, errNoUser
+	}
+	if id := req.Form.Get("user_id"); id != "" {
+		userId, _ := strconv.ParseInt(id, 10, 64)
+		if user, ok := self.users[userId]; ok {
+			return user, nil
+		}
+		return nil, errNoUser
+	}
+	return self.authUser(req)
+}
+
+func (self *Server) showStatus(req *tRequest) (interface{}, *Failure) {
+	id := req.id
+	if id == "" {
+		id = req.Form.Get("id")
+	}
+
+	statusId, _ := strconv.ParseInt(id, 10, 64)
+	status, ok := self.statuses[statusId]
+	if !ok {
+		return nil, errNoStatus
+	}
+	return self.statusJson(status, true), nil
+}
+
+func (self *Server) updateStatus(req *tRequest) (interface{}, *Failure) {
+	if req.Method != "POST" {
+		return nil, errNotPost
+	}
+	user, failure := self.authUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+
+	text := req.PostForm.Get("status")
+	if utf8.RuneCountInString(text) > kMaxStatusLength {
+		return nil, errTooLong
+	}
+	if latest := self.timeline(func(s *Status) bool { return s.UserId == user.Id }); len(latest) > 0 &&
+		latest[0].Text == text {
+		return nil, errDuplicate
+	}
+
+	replyTo, _ := strconv.ParseInt(req.PostForm.Get("in_reply_to_status_id"), 10, 64)
+	status := self.addStatus(Status{UserId: user.Id, Text: text, InReplyToStatusId: replyTo})
+	return self.statusJson(status, true), nil
+}
+
+func (self *Server) publicTimeline(req *tRequest) (interface{}, *Failure) {
+	return self.statusList(req, func(*Status) bool { return true }), nil
+}
+
+func (self *Server) userTimeline(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.targetUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return self.statusList(req, func(s *Status) bool { return s.UserId == user.Id }), nil
+}
+
+func (self *Server) homeTimeline(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.authUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return self.statusList(req, func(s *Status) bool {
+		return s.UserId == user.Id || contains(user.Friends, s.UserId)
+	}), nil
+}
+
+func (self *Server) mentions(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.authUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	mention := "@" + strings.ToLower(user.ScreenName)
+	return self.statusList(req, func(s *Status) bool {
+		return strings.Contains(strings.ToLower(s.Text), mention)
+	}), nil
+}
+
+func (self *Server) followers(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.targetUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return self.userList(user.Followers), nil
+}
+
+func (self *Server) friends(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.targetUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return self.userList(user.Friends), nil
+}
+
+func (self *Server) followerIds(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.targetUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return idPage(user.Followers), nil
+}
+
+func (self *Server) friendIds(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.targetUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return idPage(user.Friends), nil
+}
+
+func (self *Server) showUser(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.targetUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return self.userJson(user, true), nil
+}
+
+func (self *Server) lookupUsers(req *tRequest) (interface{}, *Failure) {
+	found := []object{}
+
+	for _, name := range strings.Split(req.Form.Get("screen_name"), ",") {
+		if user := self.userByName(name); name != "" && user != nil {
+			found = append(found, self.userJson(user, true))
+		}
+	}
+	for _, id := range strings.Split(req.Form.Get("user_id"), ",") {
+		userId, _ := strconv.ParseInt(id, 10, 64)
+		if user, ok := self.users[userId]; ok {
+			found = append(found, self.userJson(user, true))
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, &Failure{Status: 404, Code: 17, Message: "No user matches for specified terms."}
+	}
+	return found, nil
+}
+
+// The old search API, search.twitter.com/search.json
+func (self *Server) search(req *tRequest) (interface{}, *Failure) {
+	results := []object{}
+	for _, status := range self.page(req, self.matching(req.Form.Get("q"))) {
+		results = append(results, self.searchResultJson(status))
+	}
+	return object{"results": results}, nil
+}
+
+func (self *Server) searchTweets(req *tRequest) (interface{}, *Failure) {
+	statuses := []object{}
+	for _, status := range self.page(req, self.matching(req.Form.Get("q"))) {
+		statuses = append(statuses, self.statusJson(status, true))
+	}
+	return object{"statuses": statuses, "search_metadata": object{"query": req.Form.Get("q")}}, nil
+}
+
+// The legacy rate limit format the Api decodes
+func (self *Server) rateLimitStatus(req *tRequest) (interface{}, *Failure) {
+	limit, remaining, reset := 150, 150, time.Now().Add(kRateLimitWindow)
+	if self.rateLimit > 0 {
+		limit, remaining, reset = self.rateLimit, self.remaining, self.reset
+	}
+
+	return object{
+		"hourly_limit":          limit,
+		"remaining_hits":        remaining,
+		"reset_time_in_seconds": reset.Unix(),
+		"reset_time":            reset.UTC().Format(kTimeFormat),
+	}, nil
+}
+
+func (self *Server) verifyCredentials(req *tRequest) (interface{}, *Failure) {
+	user, failure := self.authUser(req)
+	if failure != nil {
+		return nil, failure
+	}
+	return self.userJson(user, true), nil
+}
+
+// Returns the statuses matching a search query, newest first. Supports
+// from:name and to:name, other terms must all appear in the text.
+func (self *Server) matching(query string) []*Status {
+	terms := strings.Fields(strings.ToLower(query))
+
+	return self.timeline(func(status *Status) bool {
+		text := strings.ToLower(status.Text)
+		for _, term := range terms {
+			switch {
+			case strings.HasPrefix(term, "from:"):
+				if !self.isAuthor(status.UserId, term[len("from:"):]) {
+					return false
+				}
+			case strings.HasPrefix(term, "to:"):
+				name := term[len("to:"):]
+				parent, ok := self.statuses[status.InReplyToStatusId]
+				if !strings.HasPrefix(text, "@"+name) && !(ok && self.isAuthor(parent.UserId, name)) {
+					return false
+				}
+			case !strings.Contains(text, term):
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (self *Server) isAuthor(userId int64, name string) bool {
+	user, ok := self.users[userId]
+	return ok && strings.EqualFold(user.ScreenName, name)
+}
+
+// Returns the statuses keep accepts, newest first
+func (self *Server) timeline(keep func(status *Status) bool) []*Status {
+	var statuses []*Status
+	for _, status := range self.statuses {
+		if keep(status) {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Id > statuses[j].Id })
+	return statuses
+}
+
+// Applies the since_id, max_id and count (or the old rpp) parameters
+func (self *Server) page(req *tRequest, statuses []*Status) []*Status {
+	sinceId, _ := strconv.ParseInt(req.Form.Get("since_id"), 10, 64)
+	maxId, _ := strconv.ParseInt(req.Form.Get("max_id"), 10, 64)
+	count, _ := strconv.Atoi(req.Form.Get("count"))
+	if count <= 0 {
+		count, _ = strconv.Atoi(req.Form.Get("rpp"))
+	}
+	if count <= 0 {
+		count = kDefaultCount
+	}
+
+	var page []*Status
+	for _, status := range statuses {
+		if status.Id > sinceId && (maxId == 0 || status.Id <= maxId) && len(page) < count {
+			page = append(page, status)
+		}
+	}
+	return page
+}
+
+func (self *Server) statusList(req *tRequest, keep func(status *Status) bool) []object {
+	list := []object{}
+	for _, status := range self.page(req, self.timeline(keep)) {
+		list = append(list, self.statusJson(status, true))
+	}
+	return list
+}
+
+func (self *Server) userList(ids []int64) []object {
+	list := []object{}
+	for _, id := range ids {
+		if user, ok := self.users[id]; ok {
+			list = append(list, self.userJson(user, true))
+		}
+	}
+	return list
+}
+
+func idPage(ids []int64) object {
+	if ids == nil {
+		ids = []int64{}
+	}
+	return object{"ids": ids, "next_cursor": 0, "previous_cursor": 0}
+}
+
+func contains(ids []int64, id int64) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (self Failure) String() string {
+	return fmt.Sprintf("HTTP %d, code %d: %s", self.Status, self.Code, self.Message)
+}
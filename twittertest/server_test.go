@@ -0,0 +1,186 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twittertest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, server *Server, url_ string, v interface{}) *http.Response {
+	r, err := server.Client().Get(url_)
+	if err != nil {
+		t.Fatalf("Get(%s): %s", url_, err)
+	}
+	defer r.Body.Close()
+	if v != nil {
+		json.NewDecoder(r.Body).Decode(v)
+	}
+	return r
+}
+
+type tErrors struct {
+	Errors []struct {
+		Code    int
+		Message string
+	}
+}
+
+func TestRewritesHost(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddUser(User{Id: 1, ScreenName: "jb55", Name: "Bill"})
+	id := server.AddStatus(Status{UserId: 1, Text: "hi"})
+
+	var status map[string]interface{}
+	get(t, server, "http://www.twitter.com/statuses/show/"+strconv.FormatInt(id, 10)+".json", &status)
+	if status["text"] != "hi" {
+		t.Errorf("text: got %v expected hi", status["text"])
+	}
+
+	var user map[string]interface{}
+	get(t, server, "https://api.twitter.com/1.1/users/show.json?screen_name=jb55", &user)
+	if user["name"] != "Bill" || user["statuses_count"] != 1.0 {
+		t.Errorf("user: got %v", user)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 || requests[0].Endpoint != "statuses/show" ||
+		requests[1].Endpoint != "users/show" {
+		t.Errorf("Requests: got %+v", requests)
+	}
+}
+
+func TestFailTimes(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddUser(User{Id: 1, ScreenName: "jb55"})
+	server.Fail("users/show", Failure{Status: 500, Code: 131, Message: "Internal error", Times: 2})
+
+	for i := 0; i < 2; i++ {
+		var body tErrors
+		r := get(t, server, "http://twitter.com/users/show.json?user_id=1", &body)
+		if r.StatusCode != 500 || len(body.Errors) != 1 || body.Errors[0].Code != 131 {
+			t.Errorf("request %d: got %d %+v expected 500 code 131", i, r.StatusCode, body)
+		}
+	}
+
+	if r := get(t, server, "http://twitter.com/users/show.json?user_id=1", nil); r.StatusCode != 200 {
+		t.Errorf("after failures: got %d expected 200", r.StatusCode)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetRateLimit(2)
+
+	for i := 0; i < 2; i++ {
+		r := get(t, server, "http://twitter.com/statuses/public_timeline.json", nil)
+		if r.StatusCode != 200 {
+			t.Fatalf("request %d: got %d expected 200", i, r.StatusCode)
+		}
+		if remaining := r.Header.Get("X-Rate-Limit-Remaining"); remaining != strconv.Itoa(1-i) {
+			t.Errorf("request %d: remaining %s expected %d", i, remaining, 1-i)
+		}
+	}
+
+	var body tErrors
+	r := get(t, server, "http://twitter.com/statuses/public_timeline.json", &body)
+	if r.StatusCode != 429 || len(body.Errors) != 1 || body.Errors[0].Code != 88 {
+		t.Errorf("exhausted: got %d %+v expected 429 code 88", r.StatusCode, body)
+	}
+
+	var status struct{ Remaining_hits int }
+	get(t, server, "http://twitter.com/account/rate_limit_status.json", &status)
+	if status.Remaining_hits != 0 {
+		t.Errorf("remaining_hits: got %d expected 0", status.Remaining_hits)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://twitter.com/search.json?q=go", nil)
+	if _, err := server.Client().Do(req); err == nil {
+		t.Errorf("Do: got no error expected the deadline to pass")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddUser(User{Id: 1, ScreenName: "jb55"})
+
+	post := func(user, text string) (int, tErrors, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", "https://api.twitter.com/1.1/statuses/update.json",
+			strings.NewReader(url.Values{"status": {text}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if user != "" {
+			req.SetBasicAuth(user, "secret")
+		}
+		r, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Do: %s", err)
+		}
+		defer r.Body.Close()
+
+		var body tErrors
+		var status map[string]interface{}
+		data := json.NewDecoder(r.Body)
+		if r.StatusCode == 200 {
+			data.Decode(&status)
+		} else {
+			data.Decode(&body)
+		}
+		return r.StatusCode, body, status
+	}
+
+	code, _, status := post("jb55", "hello")
+	if code != 200 || status["text"] != "hello" {
+		t.Fatalf("update: got %d %v", code, status)
+	}
+	if stored, ok := server.Status(int64(status["id"].(float64))); !ok || stored.UserId != 1 {
+		t.Errorf("Status: got %+v, %v expected a status of user 1", stored, ok)
+	}
+
+	cases := []struct {
+		user, text   string
+		status, code int
+	}{
+		{"jb55", "hello", 403, 187},
+		{"jb55", strings.Repeat("a", 281), 403, 186},
+		{"nobody", "hi", 401, 32},
+		{"", "hi", 400, 215},
+	}
+	for _, c := range cases {
+		code, body, _ := post(c.user, c.text)
+		if code != c.status || len(body.Errors) != 1 || body.Errors[0].Code != c.code {
+			t.Errorf("update(%q, %.10q): got %d %+v expected %d code %d",
+				c.user, c.text, code, body, c.status, c.code)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// The parameters of a new status, see PostStatus. Only Status is required.
+type StatusUpdate struct {
+	// The text of the status
+	Status string
+
+	// The status this one replies to. The author of that status must be
+	// mentioned in the text unless AutoPopulateReplyMetadata is set.
+	InReplyToStatusId int64
+
+	// Media to attach, as returned by the media upload endpoint
+	MediaIds []int64
+
+	// The location of the status. (0, 0) is treated as unset.
+	Latitude  float64
+	Longitude float64
+
+	// A place id from the geo API to attach the status to
+	PlaceId string
+
+	// Whether to put a pin on the exact coordinates
+	DisplayCoordinates bool
+
+	// Marks the attached media or links as sensitive
+	PossiblySensitive bool
+
+	// A quoted status or direct message deep link, attached without
+	// counting against the text length
+	AttachmentUrl string
+
+	// Fills in the mentions of the replied to conversation, excluding
+	// ExcludeReplyUserIds
+	AutoPopulateReplyMetadata bool
+	ExcludeReplyUserIds       []int64
+}
+
+// Builds the statuses/update parameters for the update
+func (self *StatusUpdate) values() url.Values {
+	params := url.Values{}
+	params.Set("status", self.Status)
+
+	if self.InReplyToStatusId != 0 {
+		params.Set("in_reply_to_status_id", strconv.FormatInt(self.InReplyToStatusId, 10))
+	}
+	if len(self.MediaIds) > 0 {
+		params.Set("media_ids", joinIds(self.MediaIds))
+	}
+	if self.Latitude != 0 || self.Longitude != 0 {
+		params.Set("lat", strconv.FormatFloat(self.Latitude, 'f', -1, 64))
+		params.Set("long", strconv.FormatFloat(self.Longitude, 'f', -1, 64))
+	}
+	if self.PlaceId != "" {
+		params.Set("place_id", self.PlaceId)
+	}
+	if self.DisplayCoordinates {
+		params.Set("display_coordinates", "true")
+	}
+	if self.PossiblySensitive {
+		params.Set("possibly_sensitive", "true")
+	}
+	if self.AttachmentUrl != "" {
+		params.Set("attachment_url", self.AttachmentUrl)
+	}
+	if self.AutoPopulateReplyMetadata {
+		params.Set("auto_populate_reply_metadata", "true")
+	}
+	if len(self.ExcludeReplyUserIds) > 0 {
+		params.Set("exclude_reply_user_ids", joinIds(self.ExcludeReplyUserIds))
+	}
+
+	return params
+}
+
+// Posts a new status for the authenticated user and returns it as created
+// by Twitter.
+//
+// The twitter.Api instance must be authenticated with OAuth, see Do. API
+// failures are returned as a *TwitterError.
+func (self *Api) PostStatus(ctx context.Context, update StatusUpdate) (Status, error) {
+	return self.statusAction(ctx, _QUERY_UPDATESTATUS, update.values())
+}
+
+// Deletes a status of the authenticated user and returns it. Fails with
+// ErrNotYours for statuses of other users and ErrNotFound for unknown ones.
+func (self *Api) DestroyStatus(ctx context.Context, id int64) (Status, error) {
+	return self.statusAction(ctx, fmt.Sprintf(_QUERY_DESTROYSTATUS, id), nil)
+}
+
+// Retweets a status and returns the new retweet. Fails with
+// ErrAlreadyRetweeted if the authenticated user did so before.
+func (self *Api) Retweet(ctx context.Context, id int64) (Status, error) {
+	return self.statusAction(ctx, fmt.Sprintf(_QUERY_RETWEET, id), nil)
+}
+
+// Undoes a retweet of the given status and returns the original status
+func (self *Api) Unretweet(ctx context.Context, id int64) (Status, error) {
+	return self.statusAction(ctx, fmt.Sprintf(_QUERY_UNRETWEET, id), nil)
+}
+
+// Favorites a status and returns it. Fails with ErrAlreadyFavorited if it
+// already is.
+func (self *Api) Favorite(ctx context.Context, id int64) (Status, error) {
+	return self.statusAction(ctx, _QUERY_FAVORITE, idParams(id))
+}
+
+// Removes a status from the authenticated user's favorites and returns it
+func (self *Api) Unfavorite(ctx context.Context, id int64) (Status, error) {
+	return self.statusAction(ctx, _QUERY_UNFAVORITE, idParams(id))
+}
+
+// POSTs to one of the status endpoints, which all answer with the
+// affected status
+func (self *Api) statusAction(ctx context.Context, url_ string, params url.Values) (Status, error) {
+	status := newEmptyTwitterStatus()
+
+	if err := self.callJson(ctx, "POST", url_, params, status); err != nil {
+		return nil, err
+	}
+
+	return self.internStatus(status), nil
+}
+
+func idParams(id int64) url.Values {
+	return url.Values{"id": {strconv.FormatInt(id, 10)}}
+}
@@ -0,0 +1,170 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Answers every request with a canned response, handing the request to
+// check first. If reply is set it builds the body instead.
+type tFakeTransport struct {
+	status int
+	body   string
+	header http.Header
+	check  func(req *http.Request)
+	reply  func(req *http.Request) string
+}
+
+func (self *tFakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := self.body
+	if self.check != nil {
+		self.check(req)
+	}
+	if self.reply != nil {
+		body = self.reply(req)
+	}
+	return &http.Response{
+		StatusCode: self.status,
+		Header:     self.header.Clone(),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func newFakeApi(transport *tFakeTransport) *Api {
+	api := NewApi()
+	api.SetHTTPClient(&http.Client{Transport: transport})
+	api.SetCredentials("jb55", "secret")
+	return api
+}
+
+func TestPostStatusSendsOptions(t *testing.T) {
+	var form url.Values
+	api := newFakeApi(&tFakeTransport{
+		status: 200,
+		body:   `{"id":10,"text":"hi","in_reply_to_status_id":9}`,
+		check: func(req *http.Request) {
+			req.ParseForm()
+			form = req.PostForm
+			if user, _, ok := req.BasicAuth(); !ok || user != "jb55" {
+				t.Errorf("request not authenticated")
+			}
+			if !strings.HasSuffix(req.URL.Path, "/statuses/update.json") {
+				t.Errorf("posted to %s", req.URL)
+			}
+		},
+	})
+
+	status, err := api.PostStatus(context.Background(), StatusUpdate{
+		Status:              "hi",
+		InReplyToStatusId:   9,
+		MediaIds:            []int64{1, 2},
+		Latitude:            37.78,
+		Longitude:           -122.4,
+		PossiblySensitive:   true,
+		ExcludeReplyUserIds: []int64{3},
+	})
+	if err != nil {
+		t.Fatalf("PostStatus: %s", err)
+	}
+	if status.GetId() != 10 {
+		t.Errorf("GetId: got %d expected 10", status.GetId())
+	}
+
+	expected := map[string]string{
+		"status":                 "hi",
+		"in_reply_to_status_id":  "9",
+		"media_ids":              "1,2",
+		"lat":                    "37.78",
+		"long":                   "-122.4",
+		"possibly_sensitive":     "true",
+		"exclude_reply_user_ids": "3",
+	}
+	for key, value := range expected {
+		if form.Get(key) != value {
+			t.Errorf("%s: got %q expected %q", key, form.Get(key), value)
+		}
+	}
+	if _, ok := form["place_id"]; ok {
+		t.Errorf("unset place_id was sent")
+	}
+}
+
+func TestPostUpdateReportsFailureOnce(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 403,
+		body:   `{"errors":[{"code":187,"message":"Status is a duplicate."}]}`,
+	})
+
+	response := api.PostUpdate("hi", 0)
+	if <-response {
+		t.Errorf("PostUpdate: got true expected false")
+	}
+	if len(response) != 0 {
+		t.Errorf("PostUpdate sent more than one result")
+	}
+
+	err, ok := api.GetLastError().(*TwitterError)
+	if !ok {
+		t.Fatalf("expected a *TwitterError")
+	}
+	if err.GetCode() != 187 || err.GetStatusCode() != 403 {
+		t.Errorf("got code %d status %d expected 187 403",
+			err.GetCode(), err.GetStatusCode())
+	}
+}
+
+func TestStatusActionErrors(t *testing.T) {
+	api := newFakeApi(&tFakeTransport{
+		status: 403,
+		body:   `{"errors":[{"code":139,"message":"You have already favorited this status."}]}`,
+		check: func(req *http.Request) {
+			req.ParseForm()
+			if req.Method != "POST" || req.PostForm.Get("id") != "20" {
+				t.Errorf("got %s %s id=%s", req.Method, req.URL, req.PostForm.Get("id"))
+			}
+		},
+	})
+
+	_, err := api.Favorite(context.Background(), 20)
+	if !errors.Is(err, ErrAlreadyFavorited) {
+		t.Errorf("Favorite: got %v expected ErrAlreadyFavorited", err)
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("Favorite: error matches ErrNotFound")
+	}
+
+	api = newFakeApi(&tFakeTransport{
+		status: 403,
+		body:   `{"errors":[{"code":183,"message":"You may not delete another user's status."}]}`,
+		check: func(req *http.Request) {
+			if !strings.HasSuffix(req.URL.Path, "/statuses/destroy/21.json") {
+				t.Errorf("posted to %s", req.URL)
+			}
+		},
+	})
+
+	if _, err = api.DestroyStatus(context.Background(), 21); !errors.Is(err, ErrNotYours) {
+		t.Errorf("DestroyStatus: got %v expected ErrNotYours", err)
+	}
+}
@@ -15,6 +15,8 @@
 //
 package twitter
 
+import "encoding/json"
+
 type User interface {
   GetId() int64
   GetName() string
@@ -37,30 +39,33 @@ type User interface {
   GetFollowersCount() int
   GetFriendsCount() int
   GetFavoritesCount() int
+  MarshalJSON() ([]byte, error)
+  RawFields
 }
 
 type tTwitterUser struct {
-  Id                           int64
-  Name                         string
-  Screen_name                  string
-  Location                     string
-  Description                  string
-  Profile_image_url            string
-  Profile_background_title     bool
-  Profile_background_image_url string
-  Profile_sidebar_fill_color   string
-  Profile_link_color           string
-  Profile_text_color           string
-  Protected                    bool
-  Utc_offset                   int
-  Url                          string
-  Timezone                     string
-  Status                       *tTwitterStatus
-  Statuses_count               int
-  Followers_count              int
-  Friends_count                int
-  Favorites_count              int
-  Error                        string
+  Id                           int64           `json:"id"`
+  Name                         string          `json:"name"`
+  Screen_name                  string          `json:"screen_name"`
+  Location                     string          `json:"location"`
+  Description                  string          `json:"description"`
+  Profile_image_url            string          `json:"profile_image_url"`
+  Profile_background_title     bool            `json:"profile_background_tile"`
+  Profile_background_image_url string          `json:"profile_background_image_url"`
+  Profile_sidebar_fill_color   string          `json:"profile_sidebar_fill_color"`
+  Profile_link_color           string          `json:"profile_link_color"`
+  Profile_text_color           string          `json:"profile_text_color"`
+  Protected                    bool            `json:"protected"`
+  Utc_offset                   int             `json:"utc_offset"`
+  Url                          string          `json:"url"`
+  Timezone                     string          `json:"time_zone"`
+  Status                       *tTwitterStatus `json:"status,omitempty"`
+  Statuses_count               int             `json:"statuses_count"`
+  Followers_count              int             `json:"followers_count"`
+  Friends_count                int             `json:"friends_count"`
+  Favorites_count              int             `json:"favourites_count"`
+  Error                        string          `json:"error,omitempty"`
+  tJsonFields
 }
 
 type tTwitterUserDummy struct {
@@ -73,59 +78,125 @@ type tTwitterUserListDummy struct {
 
 func newEmptyTwitterUser() *tTwitterUser { return new(tTwitterUser) }
 
-func (self *tTwitterUser) GetError() string { return self.Error }
+// Creates a User from its JSON representation, as returned by the
+// Twitter API or by json.Marshal on a User. Unknown members are kept
+// and written back out when the User is marshaled again.
+func ParseUser(data []byte) (User, error) {
+  user := newEmptyTwitterUser()
+  if err := json.Unmarshal(data, user); err != nil {
+    return nil, err
+  }
+  return user, nil
+}
+
+func (self *tTwitterUser) UnmarshalJSON(data []byte) error {
+  type plain tTwitterUser
+  return self.unmarshalFields(data, (*plain)(self))
+}
+
+func (self *tTwitterUser) MarshalJSON() ([]byte, error) {
+  type plain tTwitterUser
+  unlock := self.read()
+  user := *self
+  // GetStatus links the status back to us, don't follow that cycle
+  if user.Status != nil && user.Status.User == self {
+    status := *user.Status
+    status.User = nil
+    user.Status = &status
+  }
+  unlock()
+  return user.marshalFields((*plain)(&user))
+}
+
+func (self *tTwitterUser) GetError() string {
+  defer self.read()()
+  return self.Error
+}
 
-func (self *tTwitterUser) GetId() int64 { return self.Id }
+func (self *tTwitterUser) GetId() int64 {
+  defer self.read()()
+  return self.Id
+}
 
-func (self *tTwitterUser) GetName() string { return self.Name }
+func (self *tTwitterUser) GetName() string {
+  defer self.read()()
+  return self.Name
+}
 
 func (self *tTwitterUser) GetScreenName() string {
+  defer self.read()()
   return self.Screen_name
 }
 
 func (self *tTwitterUser) GetLocation() string {
+  defer self.read()()
   return self.Location
 }
 
 func (self *tTwitterUser) GetDescription() string {
+  defer self.read()()
   return self.Description
 }
 
 func (self *tTwitterUser) GetProfileImageUrl() string {
+  defer self.read()()
   return self.Profile_image_url
 }
 
 func (self *tTwitterUser) GetProfileBackgroundTitle() bool {
+  defer self.read()()
   return self.Profile_background_title
 }
 
 func (self *tTwitterUser) GetProfileSidebarFillColor() string {
+  defer self.read()()
   return self.Profile_sidebar_fill_color
 }
 
 func (self *tTwitterUser) GetProfileBackgroundImageUrl() string {
+  defer self.read()()
   return self.Profile_background_image_url
 }
 
 func (self *tTwitterUser) GetProfileLinkColor() string {
+  defer self.read()()
   return self.Profile_link_color
 }
 
 func (self *tTwitterUser) GetProfileTextColor() string {
+  defer self.read()()
   return self.Profile_text_color
 }
 
-func (self *tTwitterUser) GetProtected() bool { return self.Protected }
+func (self *tTwitterUser) GetProtected() bool {
+  defer self.read()()
+  return self.Protected
+}
 
-func (self *tTwitterUser) GetUtcOffset() int { return self.Utc_offset }
+func (self *tTwitterUser) GetUtcOffset() int {
+  defer self.read()()
+  return self.Utc_offset
+}
 
 func (self *tTwitterUser) GetTimeZone() string {
+  defer self.read()()
   return self.Timezone
 }
 
-func (self *tTwitterUser) GetURL() string { return self.Url }
+func (self *tTwitterUser) GetURL() string {
+  defer self.read()()
+  return self.Url
+}
 
 func (self *tTwitterUser) GetStatus() Status {
+  // Stored users are linked to their newest status by the EntityStore
+  if self.shared != nil {
+    defer self.read()()
+    if self.Status == nil {
+      return newEmptyTwitterStatus()
+    }
+    return self.Status
+  }
   if self.Status == nil {
     self.Status = newEmptyTwitterStatus()
   }
@@ -138,17 +209,21 @@ func (self *tTwitterUser) setStatus(status Status) {
 }
 
 func (self *tTwitterUser) GetStatusesCount() int {
+  defer self.read()()
   return self.Statuses_count
 }
 
 func (self *tTwitterUser) GetFollowersCount() int {
+  defer self.read()()
   return self.Followers_count
 }
 
 func (self *tTwitterUser) GetFriendsCount() int {
+  defer self.read()()
   return self.Friends_count
 }
 
 func (self *tTwitterUser) GetFavoritesCount() int {
+  defer self.read()()
   return self.Favorites_count
 }
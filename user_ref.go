@@ -0,0 +1,201 @@
+//
+// Copyright 2009 Bill Casarin <billcasarin@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package twitter
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// The number of users users/lookup accepts per request
+	kLookupChunk = 100
+
+	// The number of lookup requests LookupUsers sends at once
+	kLookupConcurrency = 4
+)
+
+// Identifies a user by id or screen name, or stands for the authenticated
+// user. Create one with ByID, ByScreenName or Me; the zero UserRef refers to
+// no one.
+type UserRef struct {
+	id         int64
+	screenName string
+	me         bool
+}
+
+// Returned by calls given a UserRef that refers to no one, such as ByID(0)
+// for an unset id or ByScreenName("")
+var ErrNoUser = errors.New("twitter: user reference without id or screen name")
+
+// Refers to the user with the given id. ByID(0) refers to no one, calls
+// given it fail with ErrNoUser.
+func ByID(id int64) UserRef { return UserRef{id: id} }
+
+// Refers to the user with the given screen name. ByScreenName("") refers to
+// no one, calls given it fail with ErrNoUser.
+func ByScreenName(name string) UserRef { return UserRef{screenName: name} }
+
+// Refers to the authenticated user
+func Me() UserRef { return UserRef{me: true} }
+
+func (self UserRef) IsMe() bool { return self.me }
+
+func (self UserRef) GetId() int64 { return self.id }
+
+func (self UserRef) GetScreenName() string { return self.screenName }
+
+func (self UserRef) String() string {
+	switch {
+	case self.me:
+		return "me"
+	case self.screenName != "":
+		return "@" + self.screenName
+	case self.id != 0:
+		return strconv.FormatInt(self.id, 10)
+	}
+	return "nobody"
+}
+
+// Returns ErrNoUser unless the reference is Me or names a user
+func (self UserRef) check() error {
+	if !self.me && self.screenName == "" && self.id == 0 {
+		return ErrNoUser
+	}
+	return nil
+}
+
+// Adds the user_id or screen_name parameter for the user, or nothing for
+// Me. A prefix such as "source_" turns them into source_id and
+// source_screen_name.
+func (self UserRef) addParams(params url.Values, prefix string) error {
+	idKey := prefix + "id"
+	if prefix == "" {
+		idKey = "user_id"
+	}
+
+	switch {
+	case self.me:
+	case self.screenName != "":
+		params.Set(prefix+"screen_name", self.screenName)
+	case self.id != 0:
+		params.Set(idKey, strconv.FormatInt(self.id, 10))
+	default:
+		return ErrNoUser
+	}
+	return nil
+}
+
+// Returns the users for the given references. Requests are split into
+// chunks of 100 users, up to four of which are fetched at a time. Users
+// that don't exist or are suspended are left out, Me is looked up as the
+// authenticated user.
+func (self *Api) LookupUsers(ctx context.Context, users []UserRef) ([]User, error) {
+	var wait sync.WaitGroup
+	var chunks [][]UserRef
+
+	users, err := self.resolveMe(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		if n := len(chunks); n == 0 || len(chunks[n-1]) == kLookupChunk {
+			chunks = append(chunks, make([]UserRef, 0, kLookupChunk))
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], user)
+	}
+
+	results := make([][]tTwitterUser, len(chunks))
+	errs := make([]error, len(chunks))
+	slots := make(chan bool, kLookupConcurrency)
+	for i, chunk := range chunks {
+		wait.Add(1)
+		slots <- true
+		go func(i int, chunk []UserRef) {
+			defer func() { <-slots; wait.Done() }()
+			errs[i] = self.callJson(ctx, "POST", _QUERY_LOOKUPUSERS,
+				lookupParams(chunk), &results[i])
+		}(i, chunk)
+	}
+	wait.Wait()
+
+	var found []User
+	for i := range results {
+		if errs[i] != nil && !errors.Is(errs[i], ErrNotFound) {
+			return nil, errs[i]
+		}
+		for j := range results[i] {
+			found = append(found, self.internUser(&results[i][j]))
+		}
+	}
+
+	return found, nil
+}
+
+// Replaces Me with a reference to the authenticated user's id, for the
+// endpoints taking lists of users. Fails with ErrNoUser if a reference
+// names no one.
+func (self *Api) resolveMe(ctx context.Context, users []UserRef) ([]UserRef, error) {
+	resolved := make([]UserRef, len(users))
+
+	for i, user := range users {
+		if err := user.check(); err != nil {
+			return nil, err
+		}
+		if user.IsMe() {
+			id, err := self.authenticatedUserId(ctx)
+			if err != nil {
+				return nil, err
+			}
+			user = ByID(id)
+		}
+		resolved[i] = user
+	}
+
+	return resolved, nil
+}
+
+// Builds the comma separated user_id and screen_name parameters. Me has
+// no id to send and is skipped, see resolveMe.
+func lookupParams(users []UserRef) url.Values {
+	var ids, names []string
+	params := url.Values{}
+
+	for _, user := range users {
+		if user.IsMe() {
+			continue
+		}
+		if user.screenName != "" {
+			names = append(names, user.screenName)
+		} else {
+			ids = append(ids, strconv.FormatInt(user.id, 10))
+		}
+	}
+
+	if len(ids) > 0 {
+		params.Set("user_id", strings.Join(ids, ","))
+	}
+	if len(names) > 0 {
+		params.Set("screen_name", strings.Join(names, ","))
+	}
+
+	return params
+}
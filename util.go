@@ -16,23 +16,14 @@
 package twitter
 
 import (
-	"io/ioutil"
-	"net/http"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 )
 
 func fixBrokenJson(j string) string { return `{"object":` + j + "}" }
 
-func parseResponse(response *http.Response) (string, error) {
-	var b []byte
-	b, _ = ioutil.ReadAll(response.Body)
-	response.Body.Close()
-	bStr := string(b)
-
-	return bStr, nil
-}
-
 func addQueryVariables(url_ string, variables map[string]string) string {
 	var addition string
 	newUrl := url_
@@ -50,3 +41,12 @@ func addQueryVariables(url_ string, variables map[string]string) string {
 
 	return newUrl
 }
+
+// Joins ids into the comma separated form the REST API expects
+func joinIds(ids []int64) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(strs, ",")
+}